@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+// expandBundles splits the bundled single letter flags like -la into -l -a
+// before they are parsed, as the flag package only knows one flag per argument.
+// A letter that takes a value ends the bundle and takes the rest of the
+// argument or the next argument, so -ln 5 is -l -n 5.
+func expandBundles(flags *flag.FlagSet, args []string) []string {
+	expanded := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") || len(arg) < 2 {
+			// the flags end at the first argument that is not a flag
+			return append(expanded, args[i:]...)
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if f := flags.Lookup(name); f != nil || strings.HasPrefix(arg, "--") || hasValue {
+			expanded = append(expanded, arg)
+			// the next argument is the value of the flag, don't split it
+			if f != nil && !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+				i++
+				expanded = append(expanded, args[i])
+			}
+			continue
+		}
+
+		bundle, ok := splitBundle(flags, name)
+		if !ok {
+			// leave it to the flag package to report the unknown flag
+			expanded = append(expanded, arg)
+			continue
+		}
+		expanded = append(expanded, bundle...)
+		if last := flags.Lookup(bundle[len(bundle)-1][1:]); last != nil && !isBoolFlag(last) && i+1 < len(args) {
+			i++
+			expanded = append(expanded, args[i])
+		}
+	}
+	return expanded
+}
+
+// splitBundle returns the flags of the letters of a bundle, false if a letter
+// is not a flag. A letter that takes a value gets the rest of the bundle, like -n5.
+func splitBundle(flags *flag.FlagSet, bundle string) ([]string, bool) {
+	var split []string
+	for i, r := range bundle {
+		f := flags.Lookup(string(r))
+		if f == nil {
+			return nil, false
+		}
+
+		if rest := bundle[i+len(string(r)):]; !isBoolFlag(f) && rest != "" {
+			return append(split, "-"+f.Name+"="+rest), true
+		}
+		split = append(split, "-"+f.Name)
+	}
+	return split, true
+}
+
+// isBoolFlag returns true if the flag doesn't take a value, like -l.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
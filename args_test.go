@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"slices"
+	"testing"
+)
+
+// newBundleFlags returns a few of the edls flags, of both kinds.
+func newBundleFlags() *flag.FlagSet {
+	var opts options
+	flags := flag.NewFlagSet("edls", flag.ContinueOnError)
+	flags.BoolVar(&opts.long, "l", false, "")
+	flags.BoolVar(&opts.long, "long", false, "")
+	flags.BoolFunc("a", "", setAll(&opts, false))
+	flags.BoolVar(&opts.orderReverse, "r", false, "")
+	flags.BoolVar(&opts.onePerLine, "1", false, "")
+	flags.IntVar(&opts.numberRecords, "n", 0, "")
+	flags.StringVar(&opts.pattern, "p", "", "")
+	return flags
+}
+
+func TestExpandBundles(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{args: []string{"-la"}, want: []string{"-l", "-a"}},
+		{args: []string{"-lar", "dir"}, want: []string{"-l", "-a", "-r", "dir"}},
+		// a letter that takes a value ends the bundle
+		{args: []string{"-ln", "5"}, want: []string{"-l", "-n", "5"}},
+		{args: []string{"-ln5"}, want: []string{"-l", "-n=5"}},
+		{args: []string{"-1p", "pat", "dir"}, want: []string{"-1", "-p", "pat", "dir"}},
+		// the known flags and the values are not split
+		{args: []string{"-l", "-n", "-la"}, want: []string{"-l", "-n", "-la"}},
+		{args: []string{"--long", "-p=-la"}, want: []string{"--long", "-p=-la"}},
+		// the flags end at the first argument or at --
+		{args: []string{"dir", "-la"}, want: []string{"dir", "-la"}},
+		{args: []string{"--", "-la"}, want: []string{"--", "-la"}},
+		// the unknown letters are left to the flag package
+		{args: []string{"-lz"}, want: []string{"-lz"}},
+	}
+	for _, tt := range tests {
+		if got := expandBundles(newBundleFlags(), tt.args); !slices.Equal(got, tt.want) {
+			t.Errorf("expandBundles(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestExpandBundlesParse(t *testing.T) {
+	var opts options
+	flags := flag.NewFlagSet("edls", flag.ContinueOnError)
+	flags.BoolVar(&opts.long, "l", false, "")
+	flags.BoolFunc("a", "", setAll(&opts, false))
+	flags.IntVar(&opts.numberRecords, "n", 0, "")
+
+	if err := flags.Parse(expandBundles(flags, []string{"-lan", "5", "dir"})); err != nil {
+		t.Fatal(err)
+	}
+	if !opts.long || !opts.all || opts.numberRecords != 5 {
+		t.Errorf("got long %v, all %v, n %d, want true, true, 5", opts.long, opts.all, opts.numberRecords)
+	}
+	if args := flags.Args(); !slices.Equal(args, []string{"dir"}) {
+		t.Errorf("got the arguments %q, want [dir]", args)
+	}
+}
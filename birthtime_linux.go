@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// getBirthTime returns the creation time of the named file using statx,
+// it returns false when the kernel or the file system doesn't provide it.
+func getBirthTime(name string, info os.FileInfo) (time.Time, bool) {
+	flags := 0
+	if info.Mode()&os.ModeSymlink != 0 {
+		flags = unix.AT_SYMLINK_NOFOLLOW
+	}
+
+	var stx unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, name, flags, unix.STATX_BTIME, &stx); err != nil {
+		return time.Time{}, false
+	}
+	if stx.Mask&unix.STATX_BTIME == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec)), true
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !windows
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// getBirthTime returns always false because this system has no known
+// way to get the creation time of the files.
+func getBirthTime(name string, info os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// maxLinkHops is the number of links followed by --chain before giving up, like the kernel
+const maxLinkHops = 40
+
+// markers of the --chain resolutions that don't reach a file
+const (
+	chainCircular = "(circular)"
+	chainTooLong  = "(too many links)"
+)
+
+// resolveChain returns the targets of the chain of symbolic links starting at
+// the link of the path, like b, c and /final for a -> b -> c -> /final.
+// The marker tells when the chain is circular or longer than maxLinkHops.
+func resolveChain(path string) (chain []string, marker string) {
+	current := filepath.Clean(path)
+	seen := map[string]bool{current: true}
+	for hops := 0; ; hops++ {
+		target, err := os.Readlink(current)
+		if err != nil {
+			// the end of the chain is not a link or doesn't exist
+			return chain, ""
+		}
+		if hops == maxLinkHops {
+			return chain, chainTooLong
+		}
+		chain = append(chain, target)
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(current), target)
+		}
+		current = filepath.Clean(target)
+		if seen[current] {
+			return chain, chainCircular
+		}
+		seen[current] = true
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// nameCollator sorts the names in the order of the locale of the user,
+// nil for the byte order of the C locale or --byte-order.
+var nameCollator *collate.Collator
+
+// setupCollation sets the collator of the locale of LC_ALL, LC_COLLATE or LANG,
+// like ls. There's no collator for the C and POSIX locales or an unknown one.
+func setupCollation(byteOrder bool) {
+	if byteOrder {
+		return
+	}
+
+	tag, ok := collationLocale()
+	if !ok {
+		return
+	}
+	nameCollator = collate.New(tag)
+}
+
+// collationLocale returns the language of the collation locale, like de_DE.UTF-8,
+// false for the C and POSIX locales.
+func collationLocale() (language.Tag, bool) {
+	var locale string
+	for _, env := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+		if locale = os.Getenv(env); locale != "" {
+			break
+		}
+	}
+
+	// drop the encoding and the modifier of the locale like .UTF-8 or @euro
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return language.Und, false
+	}
+
+	tag, err := language.Parse(strings.ReplaceAll(locale, "_", "-"))
+	if err != nil {
+		return language.Und, false
+	}
+	return tag, true
+}
+
+// compareNames compares the names with the collator of the locale, or by the
+// bytes ignoring the case. The equal names in the collation go by their bytes.
+// The collator is not safe for concurrent use, so it's only used by the sorts.
+func compareNames(a, b string) int {
+	if nameCollator != nil {
+		if c := nameCollator.CompareString(a, b); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// values of the --color flag
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// setupColor turns the colored output on or off according to the --color mode.
+// In auto mode the color is disabled when NO_COLOR is set or stdout is not a terminal.
+func setupColor(mode string) error {
+	switch mode {
+	case colorAuto:
+		color.NoColor = os.Getenv("NO_COLOR") != "" || !isTerminal()
+	case colorAlways:
+		color.NoColor = false
+	case colorNever:
+		color.NoColor = true
+	default:
+		return fmt.Errorf("invalid --color value %q, must be %s, %s or %s", mode, colorAuto, colorAlways, colorNever)
+	}
+	return nil
+}
+
+// isTerminal returns true if stdout is a terminal.
+func isTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
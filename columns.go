@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// longColumn renders a column of the long format
+type longColumn struct {
+	// right aligns the values like the numbers
+	right bool
+	value func(f file, opts options) string
+	// width measures the colored values, nil for the plain ones
+	width func(f file, opts options) int
+}
+
+// names of the --columns flag
+const (
+	columnInode = "inode"
+	columnMode  = "mode"
+	columnLinks = "links"
+	columnOwner = "owner"
+	columnGroup = "group"
+	columnCount = "count"
+	columnSize  = "size"
+	columnTime  = "time"
+	columnMTime = "mtime"
+	columnATime = "atime"
+	columnCTime = "ctime"
+	columnBTime = "btime"
+	columnGit   = "git"
+	columnExt   = "ext"
+	columnMIME  = "mime"
+	columnName  = "name"
+)
+
+var mapLongColumnByName = map[string]longColumn{
+	columnInode: {right: true, value: func(f file, opts options) string {
+		return strconv.FormatUint(f.inode, 10)
+	}},
+	columnMode: {value: func(f file, opts options) string {
+		if opts.octal {
+			return fmt.Sprintf("%04o", f.fileMode.Perm())
+		}
+		return f.mode
+	}},
+	columnLinks: {right: true, value: func(f file, opts options) string {
+		return strconv.FormatUint(f.nlinks, 10)
+	}},
+	columnOwner: {value: func(f file, opts options) string {
+		if opts.numericIDs {
+			return strconv.FormatUint(uint64(f.uid), 10)
+		}
+		return f.userName
+	}},
+	columnGroup: {value: func(f file, opts options) string {
+		if opts.numericIDs {
+			return strconv.FormatUint(uint64(f.gid), 10)
+		}
+		return f.groupName
+	}},
+	columnCount: {right: true, value: func(f file, opts options) string {
+		switch {
+		case !f.isDir:
+			return ""
+		case f.entries == unknownCount:
+			return "?"
+		default:
+			return strconv.Itoa(f.entries)
+		}
+	}},
+	columnSize: {
+		right: true,
+		value: func(f file, opts options) string {
+			if opts.humanReadable {
+				return colorSize(f.size, opts.sizeBase(), formatSize(f.size, opts))
+			}
+			return formatSize(f.size, opts)
+		},
+		width: func(f file, opts options) int {
+			return len(formatSize(f.size, opts))
+		},
+	},
+	columnTime:  {value: timeColumn("")},
+	columnMTime: {value: timeColumn(timeModification)},
+	columnATime: {value: timeColumn(timeAccess)},
+	columnCTime: {value: timeColumn(timeChange)},
+	columnBTime: {value: timeColumn(timeBirth)},
+	columnGit: {
+		value: func(f file, opts options) string {
+			return strings.TrimSuffix(formatGitStatus(f), " ")
+		},
+		width: func(f file, opts options) int {
+			return len(gitStatusNone)
+		},
+	},
+	columnExt: {value: func(f file, opts options) string {
+		return extensionOf(f.name)
+	}},
+	columnMIME: {value: func(f file, opts options) string {
+		return mimeType(f)
+	}},
+	columnName: {
+		value: func(f file, opts options) string {
+			return formatName(f, opts) + formatLinkTarget(f)
+		},
+		width: func(f file, opts options) int {
+			return nameWidth(f, opts) + runewidth.StringWidth(formatLinkTarget(f))
+		},
+	},
+}
+
+// timeColumn returns the renderer of the given time of the files,
+// the one selected by --time when field is empty.
+func timeColumn(field string) func(f file, opts options) string {
+	return func(f file, opts options) string {
+		shown := field
+		if shown == "" {
+			shown = opts.timeField
+		}
+
+		shownTime := displayTime(f, shown)
+		switch {
+		case shownTime.IsZero():
+			return unknownTime
+		case opts.relativeTime:
+			return humanizeTime(shownTime)
+		default:
+			return shownTime.Format(timeLayout(opts.timeStyle))
+		}
+	}
+}
+
+// defaultColumns returns the columns of the long format like ls,
+// with the inode, count, git, ext and mime columns when -i, --count, -g, --show-ext
+// and --mime are given.
+func defaultColumns(opts options) []string {
+	var columns []string
+	if opts.inode {
+		columns = append(columns, columnInode)
+	}
+	columns = append(columns, columnMode, columnLinks, columnOwner, columnGroup)
+	if opts.count {
+		columns = append(columns, columnCount)
+	}
+	columns = append(columns, columnSize, columnTime)
+	if opts.git {
+		columns = append(columns, columnGit)
+	}
+	if opts.showExt {
+		columns = append(columns, columnExt)
+	}
+	if opts.mime {
+		columns = append(columns, columnMIME)
+	}
+	return append(columns, columnName)
+}
+
+// parseColumns returns the column names of the --columns list like "mode,size,mtime,name".
+func parseColumns(list string) ([]string, error) {
+	var columns []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := mapLongColumnByName[name]; !ok {
+			names := make([]string, 0, len(mapLongColumnByName))
+			for name := range mapLongColumnByName {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			return nil, fmt.Errorf("unknown --columns name %q, must be one of %s", name, strings.Join(names, ", "))
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// columnCells returns the values of the given columns of the files, their
+// display widths and the width of each column, which is its widest value.
+func columnCells(fs []file, columns []string, opts options) (cells [][]string, cellWidths [][]int, widths []int) {
+	cells = make([][]string, len(fs))
+	cellWidths = make([][]int, len(fs))
+	widths = make([]int, len(columns))
+	for i, f := range fs {
+		cells[i] = make([]string, len(columns))
+		cellWidths[i] = make([]int, len(columns))
+		for j, name := range columns {
+			column := mapLongColumnByName[name]
+			cells[i][j] = column.value(f, opts)
+			if column.width != nil {
+				cellWidths[i][j] = column.width(f, opts)
+			} else {
+				cellWidths[i][j] = runewidth.StringWidth(cells[i][j])
+			}
+			widths[j] = max(widths[j], cellWidths[i][j])
+		}
+	}
+	return cells, cellWidths, widths
+}
+
+// padCell returns the cell padded with spaces to the width of its column,
+// on the left for the right-aligned columns.
+func padCell(cell string, cellWidth, width int, right bool) string {
+	padding := strings.Repeat(" ", width-cellWidth)
+	if right {
+		return padding + cell
+	}
+	return cell + padding
+}
+
+// formatColumns returns the lines of the files with the given columns,
+// each column padded to its widest value and separated by a space.
+func formatColumns(fs []file, columns []string, opts options) []string {
+	cells, cellWidths, widths := columnCells(fs, columns, opts)
+
+	lines := make([]string, len(fs))
+	for i := range fs {
+		var line strings.Builder
+		for j, name := range columns {
+			if j > 0 {
+				line.WriteString(" ")
+			}
+
+			right := mapLongColumnByName[name].right
+			if j == len(columns)-1 && !right {
+				// the last column doesn't need the trailing spaces
+				line.WriteString(cells[i][j])
+				continue
+			}
+			line.WriteString(padCell(cells[i][j], cellWidths[i][j], widths[j], right))
+		}
+		lines[i] = line.String()
+	}
+	return lines
+}
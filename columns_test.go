@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeColumnFollowsTimeField(t *testing.T) {
+	f := file{
+		modificationTime: time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC),
+		accessTime:       time.Date(2024, 7, 9, 8, 0, 0, 0, time.UTC),
+	}
+	column := mapLongColumnByName[columnTime]
+
+	// the renderer is shared by every run, the field of one must not stick
+	opts := testOptions()
+	for _, tt := range []struct{ timeField, want string }{
+		{timeModification, "Mar  5 14:30:00"},
+		{timeAccess, "Jul  9 08:00:00"},
+		{timeModification, "Mar  5 14:30:00"},
+	} {
+		opts.timeField = tt.timeField
+		if got := column.value(f, opts); got != tt.want {
+			t.Errorf("--time %s: got %q, want %q", tt.timeField, got, tt.want)
+		}
+	}
+}
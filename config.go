@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configFileName is the name of the config file in the home directory
+const configFileName = ".edlsrc"
+
+// mapFlagByConfigKey holds the config keys named differently than their flags,
+// the rest of the keys are the flag names themselves like sort or color.
+var mapFlagByConfigKey = map[string]string{
+	"human": "h",
+}
+
+// configPath returns the path of the config file, $XDG_CONFIG_HOME/edls/config
+// when it exists or ~/.edlsrc otherwise.
+func configPath() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		name := filepath.Join(xdg, "edls", "config")
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, configFileName)
+}
+
+// loadConfig sets the defaults of the flags from the config file, with lines
+// like "all = true" or "sort = size". It must be called before flag.Parse
+// so the flags given in the command line override the config.
+// A missing config file is not an error.
+func loadConfig(set func(name, value string) error) error {
+	name := configPath()
+	if name == "" {
+		return nil
+	}
+
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(text, "=")
+		if !found {
+			return fmt.Errorf("%s:%d: expected key = value", name, line)
+		}
+
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if flagName, ok := mapFlagByConfigKey[key]; ok {
+			key = flagName
+		}
+
+		if err := set(key, value); err != nil {
+			return fmt.Errorf("%s:%d: %v", name, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// optionsEnv is the environment variable holding default flags like "-a -l --color=always"
+const optionsEnv = "EDLS_OPTS"
+
+// envArgs returns the flags of the EDLS_OPTS environment variable, which go
+// before the command line arguments so these take precedence on conflicts.
+func envArgs() ([]string, error) {
+	args, err := splitArgs(os.Getenv(optionsEnv))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", optionsEnv, err)
+	}
+	return args, nil
+}
+
+// splitArgs splits the text into arguments by spaces like a simple shell,
+// single and double quotes group words and a backslash escapes the next character.
+func splitArgs(text string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg, escaped := false, false
+
+	for _, r := range text {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
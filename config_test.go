@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newSortFlags returns the sort flags registered like main does.
+func newSortFlags(opts *options) *flag.FlagSet {
+	flags := flag.NewFlagSet("edls", flag.ContinueOnError)
+	flags.StringVar(&opts.sortKey, "sort", "", "")
+	flags.BoolFunc("t", "", setSortKey(opts, sortTime))
+	flags.BoolFunc("s", "", setSortKey(opts, sortSize))
+	flags.BoolFunc("X", "", setSortKey(opts, sortExtension))
+	flags.BoolFunc("v", "", setSortKey(opts, sortVersion))
+	return flags
+}
+
+func TestLoadConfigSortIsOverriddenByCommandLine(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if err := os.WriteFile(filepath.Join(home, configFileName), []byte("sort = size\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: nil, want: sortSize},
+		{args: []string{"-t"}, want: sortTime},
+		{args: []string{"-X"}, want: sortExtension},
+		{args: []string{"--sort", "version"}, want: sortVersion},
+	}
+	for _, tt := range tests {
+		var opts options
+		flags := newSortFlags(&opts)
+		if err := loadConfig(flags.Set); err != nil {
+			t.Fatal(err)
+		}
+		if err := flags.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		if err := resolveSortKey(&opts); err != nil {
+			t.Fatal(err)
+		}
+		if opts.sortKey != tt.want {
+			t.Errorf("%v: got sort key %q, want %q", tt.args, opts.sortKey, tt.want)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{text: "", want: nil},
+		{text: "   ", want: nil},
+		{text: "-a -l --color=always", want: []string{"-a", "-l", "--color=always"}},
+		{text: "  -a\t-l\n", want: []string{"-a", "-l"}},
+		{text: `-p "a b"`, want: []string{"-p", "a b"}},
+		{text: `-p 'a "b" c'`, want: []string{"-p", `a "b" c`}},
+		{text: `-p "it's"`, want: []string{"-p", "it's"}},
+		{text: `--format='{{.Name}} {{.Size}}'`, want: []string{"--format={{.Name}} {{.Size}}"}},
+		// an empty quoted argument is kept
+		{text: `-p ""`, want: []string{"-p", ""}},
+		{text: `-p a\ b`, want: []string{"-p", "a b"}},
+		{text: `-p "a\"b"`, want: []string{"-p", `a"b`}},
+		// the backslash is literal inside single quotes
+		{text: `-p 'a\b'`, want: []string{"-p", `a\b`}},
+		{text: `-p "a b`, wantErr: true},
+		{text: `-p 'a`, wantErr: true},
+		{text: `-p a\`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := splitArgs(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitArgs(%q) error = %v, want error %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
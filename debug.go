@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// phaseTimings holds the time spent in each phase of the listing for --debug,
+// the phases run one after another so it needs no lock.
+type phaseTimings struct {
+	read  time.Duration
+	stat  time.Duration
+	sort  time.Duration
+	print time.Duration
+}
+
+// timings are the phase timings of the whole run
+var timings phaseTimings
+
+// track adds the time since start to the phase, to be deferred like
+// defer track(&timings.sort, time.Now()).
+func track(phase *time.Duration, start time.Time) {
+	*phase += time.Since(start)
+}
+
+// printTimings prints the phase timings to stderr, so they don't mix with the listing.
+func printTimings(start time.Time) {
+	fmt.Fprintf(os.Stderr, "edls: read %s, stat %s, sort %s, print %s, total %s\n",
+		timings.read, timings.stat, timings.sort, timings.print, time.Since(start))
+}
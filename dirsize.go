@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// unknownCount is the entries of a directory that can't be read
+const unknownCount = -1
+
+// dirSize returns the total size of the files inside the directory and its
+// subdirectories like du, the hard links of a file are counted once.
+// The subdirectories that can't be read are reported and left out.
+func dirSize(path string) int64 {
+	var total int64
+	seen := map[fileKey]bool{}
+	_ = filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			reportError(err)
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			reportError(err)
+			return nil
+		}
+
+		if getLinkCount(info.Sys()) > 1 {
+			key, ok := getFileKey(info.Sys())
+			if ok && seen[key] {
+				return nil
+			}
+			seen[key] = true
+		}
+		total += info.Size()
+		return nil
+	})
+	return total
+}
+
+// countEntries returns the number of entries directly inside the directory,
+// the hidden ones only when all is set. It's unknownCount if the directory
+// can't be read, like without permission.
+func countEntries(path string, all bool) int {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return unknownCount
+	}
+
+	var count int
+	for _, entry := range entries {
+		if all || !isHidden(entry.Name(), path) {
+			count++
+		}
+	}
+	return count
+}
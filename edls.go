@@ -1,6 +1,9 @@
 package main
 
 import (
+	"os"
+	"regexp"
+	"text/template"
 	"time"
 
 	"github.com/fatih/color"
@@ -9,6 +12,16 @@ import (
 // Windows os subsystem
 const Windows = "windows"
 
+// exit status codes like ls
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// unknownOwner is shown when the owner or group of a file is not available
+const unknownOwner = "-"
+
 // file types
 const (
 	fileRegular int = iota
@@ -17,46 +30,366 @@ const (
 	fileCompress
 	fileImage
 	fileLink
+	fileVideo
+	fileAudio
+	fileDocument
+	fileSourceCode
+	fileFifo
+	fileSocket
+	fileDevice
+	fileCharDevice
 )
 
 // file extension
 const (
+	// windows executables
 	exe = ".exe"
-	deb = ".deb"
-	zip = ".zip"
-	gz  = ".gz"
-	tar = ".tar"
-	rar = ".rar"
-	png = ".png"
-	jpg = ".jpeg"
-	gif = ".gif"
+	bat = ".bat"
+	cmd = ".cmd"
+	com = ".com"
+	ps1 = ".ps1"
+	msi = ".msi"
+
+	// compressed files
+	deb      = ".deb"
+	zip      = ".zip"
+	gz       = ".gz"
+	tar      = ".tar"
+	rar      = ".rar"
+	sevenZip = ".7z"
+	bz2      = ".bz2"
+	xz       = ".xz"
+	zst      = ".zst"
+	lz4      = ".lz4"
+	tgz      = ".tgz"
+	tbz      = ".tbz"
+
+	// images
+	png  = ".png"
+	jpg  = ".jpg"
+	jpeg = ".jpeg"
+	gif  = ".gif"
+	webp = ".webp"
+	bmp  = ".bmp"
+	tiff = ".tiff"
+	tif  = ".tif"
+	svg  = ".svg"
+	heic = ".heic"
+	avif = ".avif"
+	ico  = ".ico"
+
+	// videos
+	mp4  = ".mp4"
+	mkv  = ".mkv"
+	mov  = ".mov"
+	avi  = ".avi"
+	webm = ".webm"
+	flv  = ".flv"
+	wmv  = ".wmv"
+
+	// audios
+	mp3  = ".mp3"
+	flac = ".flac"
+	wav  = ".wav"
+	ogg  = ".ogg"
+	m4a  = ".m4a"
+	aac  = ".aac"
+	opus = ".opus"
+
+	// documents
+	pdf  = ".pdf"
+	doc  = ".doc"
+	docx = ".docx"
+	md   = ".md"
+	txt  = ".txt"
+	odt  = ".odt"
+
+	// source code, with a suffix because go is a keyword
+	goSrc   = ".go"
+	pySrc   = ".py"
+	jsSrc   = ".js"
+	rsSrc   = ".rs"
+	cSrc    = ".c"
+	javaSrc = ".java"
 )
 
 type file struct {
 	name             string
+	path             string
 	fileType         int
 	isDir            bool
 	isHidden         bool
+	inode            uint64
+	nlinks           uint64
+	entries          int
+	uid              uint32
+	gid              uint32
 	userName         string
 	groupName        string
 	size             int64
 	modificationTime time.Time
+	accessTime       time.Time
+	changeTime       time.Time
+	birthTime        time.Time
+	fileMode         os.FileMode
 	mode             string
+	linkTarget       string
+	brokenLink       bool
+	linkChain        []string
+	chainMarker      string
+	gitStatus        string
+	contentType      string
+	script           bool
+}
+
+// values of the --time flag
+const (
+	timeModification = "mtime"
+	timeAccess       = "atime"
+	timeChange       = "ctime"
+	timeBirth        = "btime"
+)
+
+// ellipsis replaces the end of the names truncated by --max-name-width
+const ellipsis = "…"
+
+// unknownTime is shown when the selected time of a file is not available
+const unknownTime = "-"
+
+// values of the --sort flag
+const (
+	sortName       = "name"
+	sortSize       = "size"
+	sortTime       = "time"
+	sortAccessTime = "atime"
+	sortChangeTime = "ctime"
+	sortExtension  = "ext"
+	sortVersion    = "version"
+	sortType       = "type"
+	sortNone       = "none"
+)
+
+// fileKey identifies a file in the system by its device and inode numbers
+type fileKey struct {
+	dev uint64
+	ino uint64
+}
+
+// options holds the values given in the command line flags
+type options struct {
+	pattern               string
+	match                 func(name string) bool
+	caseSensitive         bool
+	glob                  bool
+	ignorePattern         string
+	ignore                *regexp.Regexp
+	format                string
+	template              *template.Template
+	gitignore             bool
+	git                   bool
+	dirsOnly              bool
+	filesOnly             bool
+	audit                 bool
+	minSizeFlag           string
+	maxSizeFlag           string
+	minSize               int64
+	maxSize               int64
+	newerThanFlag         string
+	olderThanFlag         string
+	newerThan             time.Time
+	olderThan             time.Time
+	all                   bool
+	almostAll             bool
+	numberRecords         int
+	tail                  bool
+	skip                  int
+	octal                 bool
+	numericIDs            bool
+	ownerNames            bool
+	humanReadable         bool
+	si                    bool
+	blockSizeFlag         string
+	blockSize             int64
+	totalSize             bool
+	summary               bool
+	count                 bool
+	showExt               bool
+	mime                  bool
+	json                  bool
+	ndjson                bool
+	yaml                  bool
+	csv                   bool
+	markdown              bool
+	null                  bool
+	showPaths             bool
+	document              *[]file
+	relativeTime          bool
+	timeField             string
+	timeStyle             string
+	inode                 bool
+	long                  bool
+	columnsFlag           string
+	columns               []string
+	table                 bool
+	tableBorder           string
+	onePerLine            bool
+	commas                bool
+	quote                 bool
+	quoteSpecial          bool
+	classify              bool
+	color                 string
+	icons                 string
+	maxNameWidth          int
+	hyperlink             bool
+	dereference           bool
+	dereferenceArgs       bool
+	chain                 bool
+	magic                 bool
+	detectScripts         bool
+	recursive             bool
+	tree                  bool
+	treeLevel             int
+	sortKey               string
+	sortKeys              []string
+	groupDirectoriesFirst bool
+	groupByType           bool
+	byteOrder             bool
+	orderReverse          bool
+	version               bool
+	debug                 bool
+	watch                 bool
+	interval              time.Duration
+}
+
+// isStructured returns true if the output is a structured format for scripts,
+// which has no headers, icons nor colors.
+func (o options) isStructured() bool {
+	return o.json || o.ndjson || o.yaml || o.csv || o.null
+}
+
+// isDocument returns true if the output is a single document for the whole run,
+// like the JSON array or the CSV with its header.
+func (o options) isDocument() bool {
+	return o.template == nil && (o.json || o.yaml || o.csv)
+}
+
+// sizeBase returns the base of the human readable sizes, 1000 with --si.
+func (o options) sizeBase() int64 {
+	if o.si {
+		return siBase
+	}
+	return binaryBase
 }
 
 type styleFileType struct {
-	icon   string
 	color  color.Attribute
 	symbol string
 }
 
 var mapStyleByFileType = map[int]styleFileType{
-	fileRegular:    {icon: "📄"},
-	fileDirectory:  {icon: "📂", color: color.FgBlue, symbol: "/"},
-	fileExecutable: {icon: "🎰", color: color.FgGreen, symbol: "*"},
-	fileCompress:   {icon: "🎁", color: color.FgRed},
-	fileImage:      {icon: "📷", color: color.FgMagenta},
-	fileLink:       {icon: "🔗", color: color.FgCyan},
+	fileRegular:    {},
+	fileDirectory:  {color: color.FgBlue, symbol: "/"},
+	fileExecutable: {color: color.FgGreen, symbol: "*"},
+	fileCompress:   {color: color.FgRed},
+	fileImage:      {color: color.FgMagenta},
+	fileLink:       {color: color.FgCyan},
+	fileVideo:      {color: color.FgMagenta},
+	fileAudio:      {color: color.FgCyan},
+	fileDocument:   {},
+	fileSourceCode: {},
+	fileFifo:       {color: color.FgYellow, symbol: "|"},
+	fileSocket:     {color: color.FgMagenta, symbol: "="},
+	fileDevice:     {color: color.FgYellow},
+	fileCharDevice: {color: color.FgYellow},
+}
+
+// values of the --icons flag
+const (
+	iconsNerd  = "nerd"
+	iconsEmoji = "emoji"
+	iconsASCII = "ascii"
+	iconsNone  = "none"
+)
+
+// mapIconsByMode holds the icons of the file types for each --icons mode,
+// the none mode has no icons.
+var mapIconsByMode = map[string]map[int]string{
+	iconsNerd: {
+		fileRegular:    "\uf15b",
+		fileDirectory:  "\uf07b",
+		fileExecutable: "\uf489",
+		fileCompress:   "\uf410",
+		fileImage:      "\uf1c5",
+		fileLink:       "\uf0c1",
+		fileVideo:      "\uf1c8",
+		fileAudio:      "\uf1c7",
+		fileDocument:   "\uf15c",
+		fileSourceCode: "\uf121",
+		fileFifo:       "\uf0ec",
+		fileSocket:     "\uf1e6",
+		fileDevice:     "\uf0a0",
+		fileCharDevice: "\uf11c",
+	},
+	iconsEmoji: {
+		fileRegular:    "📄",
+		fileDirectory:  "📂",
+		fileExecutable: "🎰",
+		fileCompress:   "🎁",
+		fileImage:      "📷",
+		fileLink:       "🔗",
+		fileVideo:      "🎬",
+		fileAudio:      "🎵",
+		fileDocument:   "📝",
+		fileSourceCode: "💻",
+		fileFifo:       "🚰",
+		fileSocket:     "🔌",
+		fileDevice:     "💽",
+		fileCharDevice: "📟",
+	},
+	iconsASCII: {
+		fileRegular:    "[F]",
+		fileDirectory:  "[D]",
+		fileExecutable: "[X]",
+		fileCompress:   "[Z]",
+		fileImage:      "[I]",
+		fileLink:       "[L]",
+		fileVideo:      "[V]",
+		fileAudio:      "[A]",
+		fileDocument:   "[T]",
+		fileSourceCode: "[S]",
+		fileFifo:       "[|]",
+		fileSocket:     "[=]",
+		fileDevice:     "[B]",
+		fileCharDevice: "[C]",
+	},
+	iconsNone: {},
+}
+
+// mapIndicatorByFileType holds the -F indicators like ls, the types
+// without an entry have no indicator
+var mapIndicatorByFileType = map[int]string{
+	fileDirectory:  "/",
+	fileExecutable: "*",
+	fileLink:       "@",
+	fileFifo:       "|",
+	fileSocket:     "=",
+}
+
+var mapNameByFileType = map[int]string{
+	fileRegular:    "regular",
+	fileDirectory:  "directory",
+	fileExecutable: "executable",
+	fileCompress:   "compress",
+	fileImage:      "image",
+	fileLink:       "link",
+	fileVideo:      "video",
+	fileAudio:      "audio",
+	fileDocument:   "document",
+	fileSourceCode: "source",
+	fileFifo:       "fifo",
+	fileSocket:     "socket",
+	fileDevice:     "device",
+	fileCharDevice: "char-device",
 }
 
 var (
@@ -66,4 +399,8 @@ var (
 	magenta = color.New(color.FgMagenta).Add(color.Bold).SprintFunc()
 	cyan    = color.New(color.FgCyan).Add(color.Bold).SprintFunc()
 	yellow  = color.New(color.FgYellow).SprintFunc()
+	dim     = color.New(color.Faint).SprintFunc()
+
+	specialBitsColor   = color.New(color.FgWhite, color.BgRed).Add(color.Bold).SprintFunc()
+	worldWritableColor = color.New(color.FgRed).Add(color.Bold, color.Underline).SprintFunc()
 )
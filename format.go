@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bases of the human readable sizes, the binary powers of 1024 and the SI powers of 1000 of --si
+const (
+	binaryBase = 1024
+	siBase     = 1000
+)
+
+// sizeUnits are the suffixes used by humanizeSize, in powers of 1024
+const sizeUnits = "KMGTPE"
+
+// siSizeUnits are the suffixes used by humanizeSize in powers of 1000, like ls --si
+var siSizeUnits = []string{"kB", "MB", "GB", "TB", "PB", "EB"}
+
+// humanizeSize returns the size in a human readable form like 1.2K, 340M or 4.1G,
+// or 1.2kB, 340MB or 4.1GB in the SI base of 1000.
+// Sizes below 10 units keep one decimal, bigger ones are rounded to an integer.
+func humanizeSize(size, base int64) string {
+	if size < base {
+		return strconv.FormatInt(size, 10)
+	}
+
+	value := float64(size) / float64(base)
+	for i := 0; ; i++ {
+		if math.Round(value*10)/10 < 10 {
+			return fmt.Sprintf("%.1f%s", value, sizeUnit(i, base))
+		}
+		if math.Round(value) < float64(base) || i == len(sizeUnits)-1 {
+			return fmt.Sprintf("%.0f%s", value, sizeUnit(i, base))
+		}
+		value /= float64(base)
+	}
+}
+
+// formatSize returns the size as shown by the options: human readable with -h or --si,
+// which take precedence over the units of --block-size rounded up like ls, or in bytes.
+func formatSize(size int64, opts options) string {
+	switch {
+	case opts.humanReadable:
+		return humanizeSize(size, opts.sizeBase())
+	case opts.blockSize > 0:
+		return strconv.FormatInt((size+opts.blockSize-1)/opts.blockSize, 10)
+	default:
+		return strconv.FormatInt(size, 10)
+	}
+}
+
+// sizeUnit returns the suffix of the ith power of the base.
+func sizeUnit(i int, base int64) string {
+	if base == siBase {
+		return siSizeUnits[i]
+	}
+	return string(sizeUnits[i])
+}
+
+// colorSize returns the text of the size colored by its magnitude so the big files
+// stand out: the bytes dim, the kilobytes plain, the megabytes yellow and bigger ones red.
+// The magnitudes are powers of the base of the units shown, 1000 with --si.
+func colorSize(size, base int64, text string) string {
+	switch {
+	case size < base:
+		return dim(text)
+	case size < base*base:
+		return text
+	case size < base*base*base:
+		return yellow(text)
+	default:
+		return red(text)
+	}
+}
+
+// parseSize returns the number of bytes of a size like 512, 10K or 1.5M,
+// the suffixes are the same powers of 1024 used by humanizeSize in the binary base.
+func parseSize(size string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+
+	multiplier := 1.0
+	if value != "" {
+		if i := strings.IndexByte(sizeUnits, value[len(value)-1]); i >= 0 {
+			multiplier = math.Pow(1024, float64(i+1))
+			value = value[:len(value)-1]
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return int64(number * multiplier), nil
+}
+
+// timeUnits are the thresholds used by humanizeTime, from the biggest to the smallest
+var timeUnits = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"year", 365 * 24 * time.Hour},
+	{"month", 30 * 24 * time.Hour},
+	{"week", 7 * 24 * time.Hour},
+	{"day", 24 * time.Hour},
+	{"hour", time.Hour},
+	{"minute", time.Minute},
+	{"second", time.Second},
+}
+
+// humanizeTime returns the time relative to now like "2 hours ago" or "3 days ago".
+// Times in the future, usually because of clock skew, are shown like "in 5 minutes".
+func humanizeTime(t time.Time) string {
+	elapsed := time.Since(t)
+	isFuture := elapsed < 0
+	if isFuture {
+		elapsed = -elapsed
+	}
+
+	if elapsed < 10*time.Second {
+		return "just now"
+	}
+
+	for _, unit := range timeUnits {
+		if elapsed < unit.duration {
+			continue
+		}
+
+		count := int64(elapsed / unit.duration)
+		text := fmt.Sprintf("%d %s", count, unit.name)
+		if count > 1 {
+			text += "s"
+		}
+
+		if isFuture {
+			return "in " + text
+		}
+		return text + " ago"
+	}
+	return "just now"
+}
+
+// timeStyles are the presets of the --time-style flag
+var timeStyles = map[string]string{
+	"default":  time.Stamp,
+	"iso":      "2006-01-02 15:04:05",
+	"long-iso": "2006-01-02 15:04",
+	"full":     "2006-01-02 15:04:05.000000000 -0700",
+}
+
+// timeLayout returns the Go layout of the given --time-style value,
+// which is either a preset name or a custom Go layout.
+func timeLayout(style string) string {
+	if style == "" {
+		return time.Stamp
+	}
+	if layout, ok := timeStyles[style]; ok {
+		return layout
+	}
+	return style
+}
+
+// dateLayouts are the absolute dates accepted by parseTimeLimit
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTimeLimit returns the time given either as a duration before now like 7d
+// or 24h, or as an absolute date like 2006-01-02 in the local time zone.
+func parseTimeLimit(value string, now time.Time) (time.Time, error) {
+	if duration, err := parseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid duration or date %q", value)
+}
+
+// parseDuration works like time.ParseDuration but it also accepts
+// a leading number of days with the d suffix, like 7d or 1d12h.
+func parseDuration(value string) (time.Duration, error) {
+	days, rest, found := strings.Cut(value, "d")
+	if !found {
+		return time.ParseDuration(value)
+	}
+
+	count, err := strconv.Atoi(days)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	duration := time.Duration(count) * 24 * time.Hour
+	if rest != "" {
+		extra, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, err
+		}
+		duration += extra
+	}
+	return duration, nil
+}
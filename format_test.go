@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestHumanizeSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		base int64
+		want string
+	}{
+		{size: 0, base: binaryBase, want: "0"},
+		{size: 1023, base: binaryBase, want: "1023"},
+		{size: 1024, base: binaryBase, want: "1.0K"},
+		{size: 1536, base: binaryBase, want: "1.5K"},
+		{size: 10188, base: binaryBase, want: "9.9K"},
+		// 9.999K rounds to 10K, without the decimal
+		{size: 10239, base: binaryBase, want: "10K"},
+		{size: 10240, base: binaryBase, want: "10K"},
+		{size: 1048575, base: binaryBase, want: "1.0M"},
+		{size: 1 << 20, base: binaryBase, want: "1.0M"},
+		{size: 1<<30 - 1, base: binaryBase, want: "1.0G"},
+		{size: 5 << 40, base: binaryBase, want: "5.0T"},
+		{size: 1 << 60, base: binaryBase, want: "1.0E"},
+
+		{size: 0, base: siBase, want: "0"},
+		{size: 999, base: siBase, want: "999"},
+		{size: 1000, base: siBase, want: "1.0kB"},
+		{size: 1024, base: siBase, want: "1.0kB"},
+		{size: 9999, base: siBase, want: "10kB"},
+		{size: 999499, base: siBase, want: "999kB"},
+		// 999.5kB rounds to 1000kB, so it's shown in MB
+		{size: 999500, base: siBase, want: "1.0MB"},
+		{size: 1000000, base: siBase, want: "1.0MB"},
+		{size: 1048575, base: siBase, want: "1.0MB"},
+		{size: 1e9, base: siBase, want: "1.0GB"},
+		{size: 1e18, base: siBase, want: "1.0EB"},
+	}
+	for _, tt := range tests {
+		if got := humanizeSize(tt.size, tt.base); got != tt.want {
+			t.Errorf("humanizeSize(%d, %d) = %q, want %q", tt.size, tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts options
+		size int64
+		want string
+	}{
+		{name: "bytes", size: 1048575, want: "1048575"},
+		{name: "-h", opts: options{humanReadable: true}, size: 1048575, want: "1.0M"},
+		{name: "--si", opts: options{humanReadable: true, si: true}, size: 999, want: "999"},
+		{name: "--si", opts: options{humanReadable: true, si: true}, size: 1000, want: "1.0kB"},
+		// the blocks are rounded up like ls
+		{name: "--block-size", opts: options{blockSize: 1024}, size: 1025, want: "2"},
+		{name: "--block-size", opts: options{blockSize: 1024}, size: 1024, want: "1"},
+		{name: "-h over --block-size", opts: options{humanReadable: true, blockSize: 1024}, size: 1536, want: "1.5K"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.size, tt.opts); got != tt.want {
+			t.Errorf("%s: formatSize(%d) = %q, want %q", tt.name, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestColorSizeFollowsBase(t *testing.T) {
+	color.NoColor = false
+	defer func() { color.NoColor = true }()
+
+	tests := []struct {
+		size, base int64
+		want       string
+	}{
+		{size: 999, base: siBase, want: dim("999")},
+		{size: 1000, base: siBase, want: "1.0kB"},
+		// 1.0MB in SI is still below 1<<20
+		{size: 1000000, base: siBase, want: yellow("1.0MB")},
+		{size: 1000000, base: binaryBase, want: "977K"},
+		{size: 1 << 20, base: binaryBase, want: yellow("1.0M")},
+		{size: 1e9, base: siBase, want: red("1.0GB")},
+	}
+	for _, tt := range tests {
+		if got := colorSize(tt.size, tt.base, humanizeSize(tt.size, tt.base)); got != tt.want {
+			t.Errorf("colorSize(%d, %d) = %q, want %q", tt.size, tt.base, got, tt.want)
+		}
+	}
+}
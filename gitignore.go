@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// gitIgnoreFile is the name of the files holding the git ignore rules
+const gitIgnoreFile = ".gitignore"
+
+// ignoreRule is a pattern of a .gitignore file
+type ignoreRule struct {
+	base     string
+	re       *regexp.Regexp
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// gitIgnore holds the ignore rules that apply to the files of a directory,
+// from the repository root down to the directory itself.
+type gitIgnore struct {
+	dir   string
+	rules []ignoreRule
+	// ignored is set when the directory itself is ignored by its parents,
+	// then all its files are ignored as git does.
+	ignored bool
+}
+
+// loadGitIgnore returns the ignore rules of the given directory.
+// Outside a git repository there are no rules and nothing is ignored.
+func loadGitIgnore(dir string) *gitIgnore {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return &gitIgnore{}
+	}
+
+	root, ok := findGitRoot(abs)
+	if !ok {
+		return &gitIgnore{}
+	}
+
+	g := &gitIgnore{dir: abs}
+	g.rules = readIgnoreRules(filepath.Join(root, ".git", "info", "exclude"), root)
+
+	// the directories from abs up to the root, read in reverse so the
+	// rules of the deeper .gitignore files are applied last
+	var dirs []string
+	for d := abs; ; d = filepath.Dir(d) {
+		dirs = append(dirs, d)
+		if d == root {
+			break
+		}
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if i < len(dirs)-1 && g.match(dirs[i], true) {
+			g.ignored = true
+			return g
+		}
+		g.rules = append(g.rules, readIgnoreRules(filepath.Join(dirs[i], gitIgnoreFile), dirs[i])...)
+	}
+	return g
+}
+
+// isIgnored returns true if the named file of the directory is ignored by git.
+func (g *gitIgnore) isIgnored(name string, isDir bool) bool {
+	if g.ignored {
+		return true
+	}
+	if g.dir == "" {
+		return false
+	}
+	// git never tracks its own directory
+	if name == ".git" {
+		return true
+	}
+	return g.match(filepath.Join(g.dir, name), isDir)
+}
+
+// match returns true if the absolute path is ignored by the rules,
+// the last matching rule wins so a negated rule can include it again.
+func (g *gitIgnore) match(absPath string, isDir bool) bool {
+	var ignored bool
+	for _, r := range g.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+
+		rel, err := filepath.Rel(r.base, absPath)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		// patterns without a slash match the name at any level
+		target := filepath.ToSlash(rel)
+		if !r.anchored {
+			target = path.Base(target)
+		}
+
+		if r.re.MatchString(target) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// findGitRoot returns the closest parent of dir holding a .git entry.
+func findGitRoot(dir string) (string, bool) {
+	for {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// readIgnoreRules returns the rules of the given ignore file, relative to base.
+// A missing or unreadable file has no rules.
+func readIgnoreRules(name, base string) []ignoreRule {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		// a slash at the start or in the middle anchors the pattern to base
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+
+		re, err := regexp.Compile(globToRegexp(line))
+		if err != nil || line == "" {
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// globToRegexp returns the regular expression of a gitignore glob,
+// where ** matches any number of directories.
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	b.WriteString("$")
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern, target string
+		want            bool
+	}{
+		{pattern: "*.log", target: "app.log", want: true},
+		{pattern: "*.log", target: "logs/app.log", want: false},
+		{pattern: "tmp?", target: "tmp1", want: true},
+		{pattern: "tmp?", target: "tmp12", want: false},
+		{pattern: "[abc].txt", target: "b.txt", want: true},
+		{pattern: "[!abc].txt", target: "b.txt", want: false},
+		{pattern: "[!abc].txt", target: "d.txt", want: true},
+		{pattern: "**/cache", target: "cache", want: true},
+		{pattern: "**/cache", target: "a/b/cache", want: true},
+		{pattern: "docs/**/*.tmp", target: "docs/x.tmp", want: true},
+		{pattern: "docs/**/*.tmp", target: "docs/a/b/x.tmp", want: true},
+		{pattern: "docs/**", target: "docs/a/b", want: true},
+		{pattern: `\*.txt`, target: "*.txt", want: true},
+		{pattern: `\*.txt`, target: "a.txt", want: false},
+		{pattern: "a.b", target: "axb", want: false},
+		{pattern: "[unclosed", target: "[unclosed", want: true},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(globToRegexp(tt.pattern))
+		if got := re.MatchString(tt.target); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.pattern, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestGitIgnore(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{".git", "build", "src", "docs/a/b"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules := map[string]string{
+		".gitignore": "# comments and blank lines are skipped\n\n" +
+			"*.log\n" +
+			"!important.log\n" +
+			"build/\n" +
+			"!build/keep.txt\n" +
+			"/root-only.txt\n" +
+			"docs/**/*.tmp\n" +
+			"tmp?\n",
+		"src/.gitignore": "generated.go\n",
+	}
+	for name, content := range rules {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		dir, name string
+		isDir     bool
+		want      bool
+	}{
+		{dir: ".", name: "app.log", want: true},
+		// the last matching rule wins, so the negation includes it again
+		{dir: ".", name: "important.log", want: false},
+		// the patterns without a slash match at any level
+		{dir: "src", name: "debug.log", want: true},
+		{dir: ".", name: "build", isDir: true, want: true},
+		// the rules ending with a slash only match the directories
+		{dir: ".", name: "build", want: false},
+		// like git, a file can't be included again when its parent is excluded
+		{dir: "build", name: "keep.txt", want: true},
+		{dir: "build", name: "other.txt", want: true},
+		{dir: ".", name: "root-only.txt", want: true},
+		{dir: "src", name: "root-only.txt", want: false},
+		{dir: "docs", name: "x.tmp", want: true},
+		{dir: "docs/a/b", name: "x.tmp", want: true},
+		{dir: ".", name: "x.tmp", want: false},
+		{dir: ".", name: "tmp1", want: true},
+		{dir: ".", name: "tmp12", want: false},
+		// the rules of a .gitignore only apply below its directory
+		{dir: "src", name: "generated.go", want: true},
+		{dir: ".", name: "generated.go", want: false},
+		{dir: ".", name: ".git", isDir: true, want: true},
+		{dir: ".", name: "main.go", want: false},
+	}
+	for _, tt := range tests {
+		ignore := loadGitIgnore(filepath.Join(root, tt.dir))
+		if got := ignore.isIgnored(tt.name, tt.isDir); got != tt.want {
+			t.Errorf("%s in %s (dir %v): ignored = %v, want %v", tt.name, tt.dir, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestGitIgnoreOutsideRepository(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, gitIgnoreFile), []byte("*\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// without a .git entry up to the root, the rules are not git's
+	if _, ok := findGitRoot(dir); ok {
+		t.Skip("the temporary directory is inside a git repository")
+	}
+	if loadGitIgnore(dir).isIgnored("a.txt", false) {
+		t.Error("got a.txt ignored outside a repository")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitStatusNone is the marker of the files without a git status
+const gitStatusNone = "--"
+
+// loadGitStatus returns the porcelain status code of the files of the given
+// directory, like "M " for staged, " M" for modified, "??" for untracked and
+// "!!" for ignored. A subdirectory gets the status of the files inside it.
+// It returns an empty map outside a git repository or when git isn't available.
+func loadGitStatus(dir string) map[string]string {
+	statuses := map[string]string{}
+
+	// the porcelain paths are relative to the repository root
+	prefix, err := exec.Command("git", "-C", dir, "rev-parse", "--show-prefix").Output()
+	if err != nil {
+		return statuses
+	}
+
+	out, err := exec.Command("git", "-C", dir, "status", "--porcelain", "-z", "--ignored", ".").Output()
+	if err != nil {
+		return statuses
+	}
+
+	entries := bytes.Split(out, []byte{0})
+	for i := 0; i < len(entries); i++ {
+		entry := string(entries[i])
+		if len(entry) < 4 {
+			continue
+		}
+
+		code, name := entry[:2], entry[3:]
+		// renames and copies are followed by the original path
+		if code[0] == 'R' || code[0] == 'C' {
+			i++
+		}
+
+		rel, ok := strings.CutPrefix(name, strings.TrimSpace(string(prefix)))
+		if !ok {
+			continue
+		}
+
+		// only the first element matters, it's the entry of the directory
+		first, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		statuses[first] = mergeGitStatus(statuses[first], code)
+	}
+	return statuses
+}
+
+// mergeGitStatus returns the status of a directory holding files with
+// both statuses, keeping the staged and modified markers of each one.
+func mergeGitStatus(current, code string) string {
+	if current == "" {
+		return code
+	}
+
+	merged := []byte(current)
+	for i := range merged {
+		if merged[i] == ' ' || (merged[i] == '!' && code[i] != ' ') {
+			merged[i] = code[i]
+		}
+	}
+	return string(merged)
+}
+
+// formatGitStatus returns the colored git status column of the file followed by a space.
+func formatGitStatus(f file) string {
+	switch {
+	case f.gitStatus == "":
+		return gitStatusNone + " "
+	case f.gitStatus == "??":
+		return yellow(f.gitStatus) + " "
+	case f.gitStatus == "!!":
+		return cyan(f.gitStatus) + " "
+	case f.gitStatus[1] != ' ':
+		return red(f.gitStatus) + " "
+	default:
+		return green(f.gitStatus) + " "
+	}
+}
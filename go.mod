@@ -2,12 +2,19 @@ module github.com/victoryRo/edls
 
 go 1.21.5
 
-require golang.org/x/exp v0.0.0-20240213143201-ec583247a57a
+require (
+	github.com/fatih/color v1.16.0
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/mattn/go-runewidth v0.0.15
+	golang.org/x/exp v0.0.0-20240213143201-ec583247a57a
+	golang.org/x/sys v0.14.0
+	golang.org/x/term v0.14.0
+	golang.org/x/text v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
-	github.com/AJRDRGZ/fileinfo v0.0.0-20230215213109-b9a695b817d1 // indirect
-	github.com/fatih/color v1.16.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	golang.org/x/sys v0.14.0 // indirect
+	github.com/rivo/uniseg v0.2.0 // indirect
 )
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// defaultTerminalWidth is used when the terminal size can't be queried
+const defaultTerminalWidth = 80
+
+// gridGap is the number of spaces between the grid columns
+const gridGap = 2
+
+// printGrid prints the file names packed into columns that fit the terminal width.
+// When stdout is not a terminal it prints one name per line.
+func printGrid(fs []file, opts options) {
+	if !isTerminal() {
+		printNames(fs, opts)
+		return
+	}
+	width := terminalWidth()
+
+	inodes := formatInodes(fs, opts.inode)
+	names := make([]string, len(fs))
+	widths := make([]int, len(fs))
+	for i, f := range fs {
+		names[i] = inodes[i] + formatGitColumn(f, opts.git) + formatName(f, opts)
+		widths[i] = len(inodes[i]) + nameWidth(f, opts)
+		if opts.git {
+			widths[i] += len(gitStatusNone) + 1
+		}
+	}
+
+	rows, cols, colWidth := gridLayout(widths, width)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			// names fill the grid column by column, like ls
+			i := c*rows + r
+			if i >= len(names) {
+				break
+			}
+
+			fmt.Print(names[i])
+			if next := i + rows; c < cols-1 && next < len(names) {
+				fmt.Print(strings.Repeat(" ", colWidth-widths[i]))
+			}
+		}
+		fmt.Println()
+	}
+}
+
+// gridLayout returns the number of rows and columns needed to pack names
+// of the given widths into the terminal width, and the width of each column
+// which is the widest name plus the gap.
+func gridLayout(widths []int, termWidth int) (rows, cols, colWidth int) {
+	if len(widths) == 0 {
+		return 0, 0, 0
+	}
+
+	var maxWidth int
+	for _, w := range widths {
+		maxWidth = max(maxWidth, w)
+	}
+	colWidth = maxWidth + gridGap
+
+	// the last column doesn't need the gap
+	cols = max(1, (termWidth+gridGap)/colWidth)
+	rows = (len(widths) + cols - 1) / cols
+	// with the rows fixed, drop the columns that would stay empty
+	cols = (len(widths) + rows - 1) / rows
+	return rows, cols, colWidth
+}
+
+// nameWidth returns the display width of the name printed by formatName.
+func nameWidth(f file, opts options) int {
+	return runewidth.StringWidth(fileIcon(f, opts)) + runewidth.StringWidth(displayName(f, opts)) + len(fileSymbol(f, opts))
+}
+
+// terminalWidth returns the width of the terminal, taken from the COLUMNS
+// environment variable or queried from the tty of stdout, or the default width
+// when neither is available like in a pipe. The width is computed once per run.
+var terminalWidth = sync.OnceValue(func() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+
+	return defaultTerminalWidth
+})
+
+// printCommas prints the file names separated by commas like ls -m,
+// wrapped to the terminal width.
+func printCommas(fs []file, opts options) {
+	width := terminalWidth()
+	var lineWidth int
+	for i, f := range fs {
+		name, nameWidth := formatName(f, opts), nameWidth(f, opts)
+		if i < len(fs)-1 {
+			name += ","
+			nameWidth++
+		}
+
+		switch {
+		case lineWidth == 0:
+		case lineWidth+1+nameWidth > width:
+			fmt.Println()
+			lineWidth = 0
+		default:
+			fmt.Print(" ")
+			lineWidth++
+		}
+
+		fmt.Print(name)
+		lineWidth += nameWidth
+	}
+
+	if len(fs) > 0 {
+		fmt.Println()
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import "fmt"
+
+// typeGroup is a section of the --group-by-type listing
+type typeGroup struct {
+	header string
+	types  []int
+}
+
+// typeGroups holds the sections of --group-by-type in the order they're printed,
+// the file types without a group of their own are in the last one.
+var typeGroups = []typeGroup{
+	{header: "Directories", types: []int{fileDirectory}},
+	{header: "Executables", types: []int{fileExecutable}},
+	{header: "Images", types: []int{fileImage}},
+	{header: "Archives", types: []int{fileCompress}},
+	{header: "Links", types: []int{fileLink}},
+	{header: "Files"},
+}
+
+// groupByType returns the files split in the sections of typeGroups,
+// keeping the order of the sorted files inside each section.
+func groupByType(fs []file) [][]file {
+	mapGroupByFileType := map[int]int{}
+	for i, group := range typeGroups {
+		for _, fileType := range group.types {
+			mapGroupByFileType[fileType] = i
+		}
+	}
+
+	groups := make([][]file, len(typeGroups))
+	for _, f := range fs {
+		i, ok := mapGroupByFileType[f.fileType]
+		if !ok {
+			i = len(typeGroups) - 1
+		}
+		groups[i] = append(groups[i], f)
+	}
+	return groups
+}
+
+// printGroups prints each section of the files under its header, the empty
+// sections are omitted. The --summary covers all of them at the end.
+func printGroups(fs []file, opts options) error {
+	groupOpts := opts
+	groupOpts.summary = false
+
+	printed := false
+	for i, group := range groupByType(fs) {
+		if len(group) == 0 {
+			continue
+		}
+
+		if printed {
+			fmt.Println()
+		}
+		printed = true
+
+		fmt.Printf("%s:\n", typeGroups[i].header)
+		// printList already tracks the time of the whole listing for --debug
+		if err := printFormat(group, groupOpts); err != nil {
+			return err
+		}
+	}
+
+	if opts.summary {
+		printSummary(fs, opts)
+	}
+	return nil
+}
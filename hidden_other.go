@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "strings"
+
+// isHidden returns true if the file of the directory basePath is hidden,
+// which outside windows means that its name starts with a dot.
+func isHidden(filename, basePath string) bool {
+	return strings.HasPrefix(filename, ".")
+}
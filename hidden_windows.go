@@ -0,0 +1,21 @@
+package main
+
+import (
+	"path/filepath"
+	"syscall"
+)
+
+// isHidden returns true if the file of the directory basePath has the windows
+// hidden attribute, the dot files are not hidden by convention there.
+func isHidden(filename, basePath string) bool {
+	name, err := syscall.UTF16PtrFromString(filepath.Join(basePath, filename))
+	if err != nil {
+		return false
+	}
+
+	attributes, err := syscall.GetFileAttributes(name)
+	if err != nil {
+		return false
+	}
+	return attributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// hyperlink wraps the text in an OSC 8 escape sequence linking to the file
+// of the path, which the terminals that support it make clickable.
+func hyperlink(text, path string) string {
+	uri, err := fileURI(path)
+	if err != nil {
+		return text
+	}
+	return "\x1b]8;;" + uri + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// fileURI returns the absolute file:// URI of the path like file:///home/user/notes.txt,
+// the windows paths like C:\notes.txt become file:///C:/notes.txt.
+func fileURI(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	slashed := filepath.ToSlash(abs)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+
+	uri := url.URL{Scheme: "file", Path: slashed}
+	return uri.String(), nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// lsColorsEnv is the environment variable of the ls colors like "di=01;34:*.tar=01;31"
+const lsColorsEnv = "LS_COLORS"
+
+// mapLSColorKeyByFileType holds the LS_COLORS keys of the file types, the
+// regular files like images or documents are colored by their extension.
+var mapLSColorKeyByFileType = map[int]string{
+	fileDirectory:  "di",
+	fileExecutable: "ex",
+	fileLink:       "ln",
+	fileFifo:       "pi",
+	fileSocket:     "so",
+	fileDevice:     "bd",
+	fileCharDevice: "cd",
+}
+
+// lsColorExtension is a "*.ext=code" entry of LS_COLORS
+type lsColorExtension struct {
+	suffix string
+	code   string
+}
+
+// lsColorScheme holds the colors parsed from LS_COLORS
+type lsColorScheme struct {
+	types      map[string]string
+	extensions []lsColorExtension
+}
+
+// lsColors is the scheme of the LS_COLORS environment variable,
+// nil when it's not set so the built-in colors are used.
+var lsColors *lsColorScheme
+
+// loadLSColors parses the LS_COLORS environment variable.
+func loadLSColors() {
+	lsColors = parseLSColors(os.Getenv(lsColorsEnv))
+}
+
+// parseLSColors parses the entries of the LS_COLORS format, the key of an entry
+// is a file type like di or a suffix like *.tar and its value the ANSI code.
+// The entries without a value are skipped like ls does.
+func parseLSColors(value string) *lsColorScheme {
+	if value == "" {
+		return nil
+	}
+
+	scheme := &lsColorScheme{types: map[string]string{}}
+	for _, entry := range strings.Split(value, ":") {
+		key, code, found := strings.Cut(entry, "=")
+		if !found || key == "" || code == "" {
+			continue
+		}
+
+		if suffix, ok := strings.CutPrefix(key, "*"); ok {
+			scheme.extensions = append(scheme.extensions, lsColorExtension{suffix: strings.ToLower(suffix), code: code})
+			continue
+		}
+		scheme.types[key] = code
+	}
+	return scheme
+}
+
+// code returns the ANSI code of the file, false if LS_COLORS has no entry for it.
+func (s *lsColorScheme) code(f file) (string, bool) {
+	if key := lsColorSpecialKey(f); key != "" {
+		if code, ok := s.types[key]; ok {
+			return code, true
+		}
+	}
+
+	if key, ok := mapLSColorKeyByFileType[f.fileType]; ok {
+		code, ok := s.types[key]
+		// ln=target colors the links like their targets, which are not known here
+		return code, ok && code != "target"
+	}
+
+	name := strings.ToLower(f.name)
+	for _, ext := range s.extensions {
+		if strings.HasSuffix(name, ext.suffix) {
+			return ext.code, true
+		}
+	}
+
+	code, ok := s.types["fi"]
+	return code, ok
+}
+
+// lsColorSpecialKey returns the LS_COLORS key of the special bits of the file,
+// like tw for the sticky and world-writable directories or or for the broken links,
+// empty if it has none.
+func lsColorSpecialKey(f file) string {
+	sticky := f.fileMode&os.ModeSticky != 0
+	otherWritable := f.fileMode.Perm()&0o002 != 0
+
+	switch {
+	case f.brokenLink:
+		return "or"
+	case f.fileType == fileDirectory && sticky && otherWritable:
+		return "tw"
+	case f.fileType == fileDirectory && otherWritable:
+		return "ow"
+	case f.fileType == fileDirectory && sticky:
+		return "st"
+	case f.fileType != fileDirectory && f.fileMode&os.ModeSetuid != 0:
+		return "su"
+	case f.fileType != fileDirectory && f.fileMode&os.ModeSetgid != 0:
+		return "sg"
+	}
+	return ""
+}
+
+// colorLS returns the name wrapped in the ANSI code, as is when the color is off.
+func colorLS(name, code string) string {
+	if color.NoColor {
+		return name
+	}
+	return "\x1b[" + code + "m" + name + "\x1b[0m"
+}
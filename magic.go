@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+)
+
+// sniffLength is the number of bytes read to detect the content type of a file,
+// it's the most that http.DetectContentType looks at.
+const sniffLength = 512
+
+// compressContentTypes are the detected content types of the compressed files
+var compressContentTypes = []string{
+	"application/zip",
+	"application/x-gzip",
+	"application/x-rar-compressed",
+	"application/vnd.rar",
+}
+
+// detectContentType returns the content type of the named file sniffed from
+// its first bytes, so only the head of huge files is read.
+// It returns an empty string when the file is empty or can't be read.
+func detectContentType(name string) string {
+	f, err := os.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(f, head)
+	if n == 0 || (err != nil && err != io.ErrUnexpectedEOF) {
+		return ""
+	}
+	return http.DetectContentType(head[:n])
+}
+
+// shebang starts the scripts run by the interpreter of their first line
+const shebang = "#!"
+
+// hasShebang returns true if the named file starts with #!, only its first
+// two bytes are read so huge and binary files cost the same.
+func hasShebang(name string) bool {
+	f, err := os.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	head := make([]byte, len(shebang))
+	if _, err := io.ReadFull(f, head); err != nil {
+		return false
+	}
+	return string(head) == shebang
+}
@@ -1,89 +1,973 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
 	"os"
-	"path"
+	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
-	"github.com/AJRDRGZ/fileinfo"
 	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
 	"golang.org/x/exp/constraints"
 )
 
 func main() {
+	start := time.Now()
+	var opts options
+
 	// filter pattern
-	flagPattern := flag.String("p", "", "filter by pattern")
-	flagAll := flag.Bool("a", false, "all files including hide files")
-	flagNumberRecords := flag.Int("n", 0, "number of records")
+	flag.StringVar(&opts.pattern, "p", "", "filter by pattern")
+	flag.StringVar(&opts.pattern, "pattern", "", "filter by pattern")
+	flag.BoolVar(&opts.caseSensitive, "case-sensitive", false, "match the -p pattern case sensitively")
+	flag.BoolVar(&opts.glob, "glob", false, "match the -p pattern as a shell glob like *.go, anchored to the whole name")
+	flag.StringVar(&opts.ignorePattern, "I", "", "ignore the files matching the pattern")
+	flag.StringVar(&opts.ignorePattern, "ignore", "", "ignore the files matching the pattern")
+	flag.BoolVar(&opts.gitignore, "gitignore", false, "hide the files ignored by the .gitignore rules")
+	flag.BoolVar(&opts.git, "g", false, "show the git status of each file")
+	flag.BoolVar(&opts.git, "git", false, "show the git status of each file")
+	flag.BoolVar(&opts.dirsOnly, "d", false, "list only directories")
+	flag.BoolVar(&opts.dirsOnly, "dirs-only", false, "list only directories")
+	flag.BoolVar(&opts.filesOnly, "files-only", false, "list only files that are not directories")
+	flag.StringVar(&opts.minSizeFlag, "min-size", "", "list only files of at least this size like 10K or 2M, directories are always listed")
+	flag.StringVar(&opts.maxSizeFlag, "max-size", "", "list only files of at most this size like 10K or 2M, directories are always listed")
+	flag.StringVar(&opts.newerThanFlag, "newer-than", "", "list only files modified after a duration ago like 7d or 24h, or a date like 2006-01-02")
+	flag.StringVar(&opts.olderThanFlag, "older-than", "", "list only files modified before a duration ago like 7d or 24h, or a date like 2006-01-02")
+	flag.BoolVar(&opts.audit, "audit", false, "list only the world-writable files")
+	flag.BoolFunc("a", "all files including hide files", setAll(&opts, false))
+	flag.BoolFunc("all", "all files including hide files", setAll(&opts, false))
+	flag.BoolFunc("A", "like -a without the . and .. entries", setAll(&opts, true))
+	flag.BoolFunc("almost-all", "like -a without the . and .. entries", setAll(&opts, true))
+	flag.IntVar(&opts.numberRecords, "n", 0, "number of records, a negative number takes them from the end")
+	flag.IntVar(&opts.numberRecords, "number", 0, "number of records, a negative number takes them from the end")
+	flag.IntVar(&opts.skip, "skip", 0, "skip this number of records before the -n ones, to list by pages")
+	flag.BoolVar(&opts.tail, "tail", false, "take the -n records from the end, like the most recent files with -t")
+	flag.BoolVar(&opts.octal, "o", false, "show the permissions in octal like 0755")
+	flag.BoolVar(&opts.octal, "octal", false, "show the permissions in octal like 0755")
+	flag.BoolVar(&opts.numericIDs, "numeric-uid-gid", false, "show numeric user and group ids")
+	flag.BoolVar(&opts.humanReadable, "h", false, "human readable sizes like 1.2K or 340M")
+	flag.BoolVar(&opts.humanReadable, "human-readable", false, "human readable sizes like 1.2K or 340M")
+	flag.BoolVar(&opts.si, "si", false, "like -h but in powers of 1000 like 1.2kB or 340MB")
+	flag.StringVar(&opts.blockSizeFlag, "block-size", "", "show the sizes in units of this size like 1K or 1M, rounded up, -h and --si take precedence")
+	flag.BoolVar(&opts.totalSize, "total-size", false, "show the total size of the files inside the directories like du")
+	flag.BoolVar(&opts.count, "count", false, "show the number of entries inside the directories in the long format")
+	flag.BoolVar(&opts.showExt, "show-ext", false, "show the extension of the files in a column of the long format, handy with -X")
+	flag.BoolVar(&opts.mime, "mime", false, "show the MIME type of the files by their extension in a column of the long format, by their content too with --magic")
+	flag.BoolVar(&opts.summary, "summary", false, "print the number of files of each type and their total size at the end")
+	flag.BoolVar(&opts.watch, "watch", false, "keep running and list again when the directories change")
+	flag.DurationVar(&opts.interval, "interval", 0, "list again every interval like 2s, with --watch how often to check for changes when the system can't notify them")
+	flag.BoolVar(&opts.json, "j", false, "print the files as a JSON array")
+	flag.BoolVar(&opts.json, "json", false, "print the files as a JSON array")
+	flag.BoolVar(&opts.relativeTime, "relative", false, "show the modification time relative to now like 2 hours ago")
+	flag.StringVar(&opts.timeField, "time", timeModification, "time shown in the long format: mtime, atime, ctime or btime")
+	flag.StringVar(&opts.timeStyle, "time-style", "default", "time format: default, iso, long-iso, full or a Go layout")
+	flag.BoolVar(&opts.inode, "i", false, "print the inode number of each file")
+	flag.BoolVar(&opts.inode, "inode", false, "print the inode number of each file")
+	flag.BoolVar(&opts.long, "l", false, "long format with mode, owner, size and time, the total line sums only the printed files like the ones kept by -n")
+	flag.BoolVar(&opts.long, "long", false, "long format with mode, owner, size and time, the total line sums only the printed files like the ones kept by -n")
+	flag.BoolVar(&opts.onePerLine, "1", false, "list one file name per line")
+	flag.BoolVar(&opts.onePerLine, "one-per-line", false, "list one file name per line")
+	flag.BoolVar(&opts.commas, "m", false, "list the file names separated by commas")
+	flag.BoolVar(&opts.commas, "commas", false, "list the file names separated by commas")
+	flag.StringVar(&opts.color, "color", colorAuto, "colorize the output: auto, always or never")
+	flag.IntVar(&opts.maxNameWidth, "max-name-width", 0, "truncate the names wider than this with an ellipsis, 0 means no limit")
+	flag.BoolVar(&opts.hyperlink, "hyperlink", false, "make the names clickable links to the files in the terminals that support it")
+	flag.StringVar(&opts.columnsFlag, "columns", "", "columns of the long format like mode,size,mtime,name")
+	flag.BoolVar(&opts.table, "table", false, "print the long format inside a table with borders")
+	flag.StringVar(&opts.tableBorder, "table-border", tableBox, "borders of the --table: box or ascii for the terminals without box-drawing characters")
+	flag.StringVar(&opts.format, "format", "", "print each file with a Go template like '{{.Name}} {{human .Size}}'")
+	flag.StringVar(&opts.icons, "icons", iconsEmoji, "icons of the file types: nerd, emoji, ascii or none")
+	flag.BoolVar(&opts.dereference, "L", false, "show the information of the symbolic link targets")
+	flag.BoolVar(&opts.dereference, "dereference", false, "show the information of the symbolic link targets")
+	flag.BoolVar(&opts.chain, "chain", false, "show the whole chain of the symbolic links to other links like a -> b -> c")
+	flag.BoolVar(&opts.dereferenceArgs, "H", false, "show the information of the targets of the symbolic links given as arguments, not the ones inside the directories")
+	flag.BoolVar(&opts.dereferenceArgs, "dereference-command-line", false, "show the information of the targets of the symbolic links given as arguments, not the ones inside the directories")
+	flag.BoolVar(&opts.detectScripts, "detect-scripts", false, "show the files starting with a #! shebang as executables even without the x permission")
+	flag.BoolVar(&opts.magic, "magic", false, "detect the type of the regular files by their content")
+	flag.BoolVar(&opts.markdown, "markdown", false, "print the files as a Markdown table")
+	flag.BoolVar(&opts.ndjson, "ndjson", false, "print a JSON object per line for each file, as the files are read with --sort none")
+	flag.BoolVar(&opts.yaml, "yaml", false, "print the files as a YAML sequence")
+	flag.BoolVar(&opts.csv, "csv", false, "print the files as comma separated values")
+	flag.BoolVar(&opts.null, "0", false, "print the file names separated by NUL bytes for xargs -0")
+	flag.BoolVar(&opts.null, "null", false, "print the file names separated by NUL bytes for xargs -0")
+	flag.BoolVar(&opts.quote, "Q", false, "always quote the file names, by default only the names with spaces or special characters on a terminal")
+	flag.BoolVar(&opts.quote, "quote", false, "always quote the file names, by default only the names with spaces or special characters on a terminal")
+	flag.BoolVar(&opts.classify, "F", false, "append an indicator to the names: / directory, * executable, @ link")
+	flag.BoolVar(&opts.classify, "classify", false, "append an indicator to the names: / directory, * executable, @ link")
+	flag.BoolVar(&opts.recursive, "R", false, "list subdirectories recursively")
+	flag.BoolVar(&opts.recursive, "recursive", false, "list subdirectories recursively")
+	flag.BoolVar(&opts.tree, "tree", false, "list subdirectories recursively as a tree")
+	flag.IntVar(&opts.treeLevel, "level", 0, "max depth of the tree, 0 means no limit")
+
+	// order flags
+	flag.StringVar(&opts.sortKey, "sort", "", "sort by name, size, time, atime, ctime, ext, version, type or none, or by a list of keys like type,name")
+	flag.BoolFunc("t", "sort by time, oldest first", setSortKey(&opts, sortTime))
+	flag.BoolFunc("sort-by-time", "sort by time, oldest first", setSortKey(&opts, sortTime))
+	flag.BoolFunc("s", "sort by file size, smallest first", setSortKey(&opts, sortSize))
+	flag.BoolFunc("sort-by-size", "sort by file size, smallest first", setSortKey(&opts, sortSize))
+	flag.BoolFunc("X", "sort by file extension, files without extension first", setSortKey(&opts, sortExtension))
+	flag.BoolFunc("sort-by-extension", "sort by file extension, files without extension first", setSortKey(&opts, sortExtension))
+	flag.BoolFunc("v", "natural sort of the numbers within names, file2 before file10", setSortKey(&opts, sortVersion))
+	flag.BoolFunc("sort-by-version", "natural sort of the numbers within names, file2 before file10", setSortKey(&opts, sortVersion))
+	flag.BoolVar(&opts.byteOrder, "byte-order", false, "sort the names by their bytes, faster than the order of the locale")
+	flag.BoolVar(&opts.groupByType, "group-by-type", false, "print the files in sections of directories, executables, images, archives, links and files")
+	flag.BoolVar(&opts.groupDirectoriesFirst, "group-directories-first", false, "list directories before files")
+	flag.BoolVar(&opts.orderReverse, "r", false, "reverse order while sorting")
+	flag.BoolVar(&opts.orderReverse, "reverse", false, "reverse order while sorting")
+
+	flag.BoolVar(&opts.debug, "debug", false, "print the time spent reading, statting, sorting and printing to stderr")
+	flag.BoolVar(&opts.version, "version", false, "print the version and exit")
+	flag.Usage = usage
+
+	if err := loadConfig(flag.Set); err != nil {
+		usageError(err)
+	}
+
+	args, err := envArgs()
+	if err != nil {
+		usageError(err)
+	}
+
+	// flag.ExitOnError makes Parse exit on its own errors
+	_ = flag.CommandLine.Parse(expandBundles(flag.CommandLine, append(args, os.Args[1:]...)))
+
+	if opts.version {
+		fmt.Printf("edls %s\n", version)
+		return
+	}
+
+	if err := setupColor(opts.color); err != nil {
+		usageError(err)
+	}
+	loadLSColors()
+	// the hyperlinks are escape sequences like the colors, only for the terminals
+	opts.hyperlink = opts.hyperlink && isTerminal() && !color.NoColor
+	// like ls, the names are quoted by default only for the terminals so a pipe
+	// to xargs gets them as they are
+	opts.quoteSpecial = isTerminal()
+
+	if opts.dirsOnly && opts.filesOnly {
+		usageError(errors.New("the --dirs-only and --files-only flags are mutually exclusive"))
+	}
+
+	if opts.interval < 0 {
+		usageError(fmt.Errorf("invalid --interval value %s, must not be negative", opts.interval))
+	}
+
+	if opts.skip < 0 {
+		usageError(fmt.Errorf("invalid --skip value %d, must not be negative", opts.skip))
+	}
+
+	if opts.maxNameWidth < 0 {
+		usageError(fmt.Errorf("invalid --max-name-width value %d, must not be negative", opts.maxNameWidth))
+	}
+
+	if _, ok := mapIconsByMode[opts.icons]; !ok {
+		usageError(fmt.Errorf("invalid --icons value %q, must be %s, %s, %s or %s",
+			opts.icons, iconsNerd, iconsEmoji, iconsASCII, iconsNone))
+	}
+
+	switch opts.timeField {
+	case timeModification, timeAccess, timeChange, timeBirth:
+	default:
+		usageError(fmt.Errorf("invalid --time value %q, must be %s, %s, %s or %s",
+			opts.timeField, timeModification, timeAccess, timeChange, timeBirth))
+	}
+
+	if err := resolveSortKey(&opts); err != nil {
+		usageError(err)
+	}
+	setupCollation(opts.byteOrder)
+
+	if err := parseSizeRange(&opts); err != nil {
+		usageError(err)
+	}
+
+	if err := parseTimeWindow(&opts); err != nil {
+		usageError(err)
+	}
+
+	if opts.pattern != "" {
+		match, err := compileMatcher(opts.pattern, opts.caseSensitive, opts.glob)
+		if err != nil {
+			usageError(err)
+		}
+		opts.match = match
+	}
+
+	if opts.ignorePattern != "" {
+		ignore, err := compilePattern(opts.ignorePattern, opts.caseSensitive)
+		if err != nil {
+			usageError(err)
+		}
+		opts.ignore = ignore
+	}
+
+	if opts.columnsFlag != "" {
+		columns, err := parseColumns(opts.columnsFlag)
+		if err != nil {
+			usageError(err)
+		}
+		// choosing the columns implies the long format
+		opts.columns, opts.long = columns, true
+	}
+
+	// like ls, --si implies the human readable sizes
+	opts.humanReadable = opts.humanReadable || opts.si
+
+	if _, ok := mapTableBorderByStyle[opts.tableBorder]; !ok {
+		usageError(fmt.Errorf("invalid --table-border value %q, must be %s or %s", opts.tableBorder, tableBox, tableASCII))
+	}
+
+	// the count, the extension, the MIME type and the table are of the long format
+	opts.long = opts.long || opts.count || opts.showExt || opts.mime || opts.table
+
+	if opts.format != "" {
+		tmpl, err := compileFormat(opts.format)
+		if err != nil {
+			usageError(err)
+		}
+		opts.template = tmpl
+	}
+
+	// the lookups of the names are slow on a broken NFS name resolution,
+	// they're only done when the names are printed
+	opts.ownerNames = needsOwnerNames(opts)
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	for i, path := range paths {
+		paths[i] = expandPath(path)
+	}
+
+	switch {
+	case opts.watch:
+		watchPaths(paths, opts)
+	case opts.interval > 0:
+		refreshPaths(paths, opts)
+	default:
+		listPaths(paths, opts)
+	}
+
+	if opts.debug {
+		printTimings(start)
+	}
+	os.Exit(exitStatus)
+}
+
+// listPaths prints the listings of the paths given in the command line.
+// The errors are reported and the listing goes on with the rest of the paths.
+func listPaths(paths []string, opts options) {
+	// the files of several listings are told apart by their paths
+	opts.showPaths = len(paths) > 1 || opts.recursive
+
+	// a document like the CSV output must have a single header, so the
+	// listings are gathered and printed at the end
+	if opts.isDocument() {
+		var document []file
+		opts.document = &document
+		defer func() {
+			opts.document = nil
+			if err := printList(document, opts); err != nil {
+				reportError(err)
+			}
+		}()
+	}
+
+	// like ls, the file arguments are listed together before the directories
+	var fileArgs []file
+	var dirPaths []string
+	for _, path := range paths {
+		info, err := os.Lstat(path)
+		if err != nil {
+			reportError(err)
+			continue
+		}
+
+		// like ls, a link to a directory is shown as the link itself, unless it's
+		// given with a trailing slash like mylink/ or -H or -L follow it
+		if info.Mode()&os.ModeSymlink != 0 && (hasTrailingSlash(path) || opts.dereferenceArgs || opts.dereference) {
+			if target, err := os.Stat(path); err == nil {
+				info = target
+			}
+		}
+
+		if info.IsDir() {
+			dirPaths = append(dirPaths, path)
+			continue
+		}
+
+		archivo, err := getPathFile(path, opts)
+		if err != nil {
+			reportError(err)
+			continue
+		}
+		fileArgs = append(fileArgs, archivo)
+	}
+
+	if len(fileArgs) > 0 {
+		sortFiles(fileArgs, opts)
+		if err := printList(limitFiles(fileArgs, opts), opts); err != nil {
+			reportError(err)
+		}
+	}
+
+	// ancestors holds the directories of the current -R branch to avoid symlink cycles
+	ancestors := map[fileKey]bool{}
+
+	for i, path := range dirPaths {
+		if opts.tree {
+			if err := printTree(path, opts); err != nil {
+				reportError(err)
+			}
+			continue
+		}
+
+		// like ls, each listing gets a header when there are several paths
+		// or a recursive walk, except in the structured outputs that must stay parseable
+		if (len(paths) > 1 || opts.recursive) && !opts.isStructured() {
+			if i > 0 || len(fileArgs) > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", path)
+		}
+
+		// the argument is the first ancestor of its walk, for the links back to it
+		key, err := enterDir(path, ancestors)
+		if err != nil {
+			reportError(err)
+			continue
+		}
+		if err := listPath(path, opts, ancestors); err != nil {
+			reportError(err)
+		}
+		delete(ancestors, key)
+	}
+}
+
+// needsOwnerNames returns true if the output shows the names of the owners and
+// groups, which are not needed with --numeric-uid-gid or without the owner and
+// group columns. The outputs take precedence in the same order as in printList.
+func needsOwnerNames(opts options) bool {
+	switch {
+	case opts.numericIDs:
+		return false
+	case opts.template != nil:
+		return true
+	case opts.json || opts.ndjson || opts.yaml:
+		return opts.long
+	case opts.csv:
+		return true
+	case opts.null || opts.markdown || !opts.long:
+		return false
+	}
+
+	columns := opts.columns
+	if len(columns) == 0 {
+		columns = defaultColumns(opts)
+	}
+	return slices.Contains(columns, columnOwner) || slices.Contains(columns, columnGroup)
+}
+
+// setSortKey returns the function of the -t, -s, -X and -v flags, which set the
+// --sort key as they're parsed so the command line overrides the --sort of the
+// config and EDLS_OPTS, and the last one given wins.
+func setSortKey(opts *options, key string) func(string) error {
+	return func(value string) error {
+		on, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		if on {
+			opts.sortKey = key
+		} else if opts.sortKey == key {
+			opts.sortKey = ""
+		}
+		return nil
+	}
+}
+
+// setAll returns the function of the -a and -A flags, which set the same
+// options so the last one given wins, like ls.
+func setAll(opts *options, almost bool) func(string) error {
+	return func(value string) error {
+		all, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		opts.all, opts.almostAll = all, all && almost
+		return nil
+	}
+}
+
+// exitStatus is the status edls exits with, like ls it's a failure
+// when any error was reported during the listing.
+var exitStatus = exitOK
+
+// errorMu guards exitStatus and stderr, as the errors are reported
+// by the workers that read the files concurrently.
+var errorMu sync.Mutex
+
+// reportError prints the error to stderr and makes edls exit with a failure,
+// the listing goes on with the rest of the files.
+func reportError(err error) {
+	errorMu.Lock()
+	defer errorMu.Unlock()
+
+	fmt.Fprintf(os.Stderr, "edls: %v\n", err)
+	exitStatus = exitFailure
+}
+
+// usageError prints the error of the command line flags to stderr and exits.
+func usageError(err error) {
+	fmt.Fprintf(os.Stderr, "edls: %v\n", err)
+	os.Exit(exitUsage)
+}
+
+// expandPath expands the environment variables and a leading ~ of the path,
+// for the shells that don't expand them or when edls is run programmatically.
+func expandPath(path string) string {
+	path = os.ExpandEnv(path)
+
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	// the trailing slash of a link to a directory lists the directory
+	expanded := filepath.Join(home, path[1:])
+	if hasTrailingSlash(path) && path != "~/" {
+		expanded += string(filepath.Separator)
+	}
+	return expanded
+}
+
+// hasTrailingSlash returns true if the path ends with a separator like mylink/.
+func hasTrailingSlash(path string) bool {
+	return path != "" && os.IsPathSeparator(path[len(path)-1])
+}
+
+// getPathFile returns the file object of a path given in the command line
+// which is not a directory, its name is the path as given.
+// With -H a symbolic link shows the information of its target, unlike
+// the links found inside the directories.
+func getPathFile(path string, opts options) (file, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return file{}, err
+	}
+
+	if opts.dereferenceArgs && info.Mode()&os.ModeSymlink != 0 {
+		// a broken link keeps its own information
+		if target, err := os.Stat(path); err == nil {
+			info = target
+		}
+	}
+
+	entry := fs.FileInfoToDirEntry(info)
+	archivo, err := getFile(filepath.Dir(path), entry, isHidden(entry.Name(), filepath.Dir(path)), opts)
+	if err != nil {
+		return file{}, err
+	}
+
+	archivo.name = path
+	return archivo, nil
+}
+
+// parseSortKeys validates the list of keys of --sort like type,name, which sort
+// by each key in turn, and the type key alone. The name is the implicit last key,
+// so the ties don't keep the order of the directory.
+func parseSortKeys(opts *options) error {
+	keys := strings.Split(opts.sortKey, ",")
+	if len(keys) == 1 && keys[0] != sortType {
+		return fmt.Errorf("invalid --sort value %q, must be %s, %s, %s, %s, %s, %s, %s, %s, %s or a list of them like type,name",
+			opts.sortKey, sortName, sortSize, sortTime, sortAccessTime, sortChangeTime, sortExtension, sortVersion, sortType, sortNone)
+	}
+
+	for _, key := range keys {
+		if _, ok := mapCompareBySortKey[key]; !ok {
+			return fmt.Errorf("invalid --sort key %q in %q", key, opts.sortKey)
+		}
+	}
+	if !slices.Contains(keys, sortName) {
+		keys = append(keys, sortName)
+	}
+	opts.sortKeys = keys
+	return nil
+}
+
+// resolveSortKey validates the --sort key of the options, which is also set by
+// -t, -s, -X and -v so the last one given wins. Without them it sorts by name.
+func resolveSortKey(opts *options) error {
+	switch opts.sortKey {
+	case "":
+		opts.sortKey = sortName
+		return nil
+	case sortName, sortSize, sortTime, sortAccessTime, sortChangeTime, sortExtension, sortVersion, sortNone:
+		return nil
+	default:
+		return parseSortKeys(opts)
+	}
+}
+
+// parseSizeRange sets the size range of the options from the --min-size
+// and --max-size flags, without a limit the range stays open.
+func parseSizeRange(opts *options) error {
+	opts.maxSize = math.MaxInt64
+
+	if opts.minSizeFlag != "" {
+		size, err := parseSize(opts.minSizeFlag)
+		if err != nil {
+			return fmt.Errorf("--min-size: %v", err)
+		}
+		opts.minSize = size
+	}
+
+	if opts.maxSizeFlag != "" {
+		size, err := parseSize(opts.maxSizeFlag)
+		if err != nil {
+			return fmt.Errorf("--max-size: %v", err)
+		}
+		opts.maxSize = size
+	}
+
+	if opts.blockSizeFlag != "" {
+		size, err := parseSize(opts.blockSizeFlag)
+		if err != nil || size == 0 {
+			return fmt.Errorf("--block-size: invalid size %q", opts.blockSizeFlag)
+		}
+		opts.blockSize = size
+	}
+	return nil
+}
+
+// parseTimeWindow sets the modification time window of the options from
+// the --newer-than and --older-than flags, a zero time means no limit.
+func parseTimeWindow(opts *options) error {
+	now := time.Now()
+
+	if opts.newerThanFlag != "" {
+		t, err := parseTimeLimit(opts.newerThanFlag, now)
+		if err != nil {
+			return fmt.Errorf("--newer-than: %v", err)
+		}
+		opts.newerThan = t
+	}
+
+	if opts.olderThanFlag != "" {
+		t, err := parseTimeLimit(opts.olderThanFlag, now)
+		if err != nil {
+			return fmt.Errorf("--older-than: %v", err)
+		}
+		opts.olderThan = t
+	}
+	return nil
+}
+
+// compilePattern compiles the regular expression of a filter flag,
+// which is case insensitive unless caseSensitive is set.
+func compilePattern(pattern string, caseSensitive bool) (*regexp.Regexp, error) {
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// compileMatcher returns the function matching the names against the -p pattern,
+// which is a regular expression or, with glob, a shell pattern anchored to the whole name.
+func compileMatcher(pattern string, caseSensitive, glob bool) (func(name string) bool, error) {
+	if !glob {
+		re, err := compilePattern(pattern, caseSensitive)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+
+	if !caseSensitive {
+		pattern = strings.ToLower(pattern)
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %v", pattern, err)
+	}
+
+	return func(name string) bool {
+		if !caseSensitive {
+			name = strings.ToLower(name)
+		}
+		// the pattern was validated, so Match can't fail
+		isMatch, _ := filepath.Match(pattern, name)
+		return isMatch
+	}, nil
+}
+
+// listPath prints the files of the given directory according to the options,
+// with -R it also walks into its subdirectories.
+// It returns an error if the directory can't be read.
+func listPath(path string, opts options, ancestors map[fileKey]bool) error {
+	var fs []file
+	if canStream(opts) {
+		dirs, err := streamFiles(path, opts)
+		if err != nil {
+			return err
+		}
+		fs = dirs
+	} else {
+		all, err := readFiles(path, opts)
+		if err != nil {
+			return err
+		}
+
+		fs = limitFiles(all, opts)
+		if err := printList(fs, opts); err != nil {
+			return err
+		}
+	}
+
+	if !opts.recursive {
+		return nil
+	}
+
+	// errors in a subdirectory don't stop the walk
+	for _, f := range fs {
+		if !f.isDir || isDotEntry(f) {
+			continue
+		}
+
+		subPath := filepath.Join(path, f.name)
+		key, err := enterDir(subPath, ancestors)
+		if err != nil {
+			reportError(err)
+			continue
+		}
+		if !opts.isStructured() {
+			fmt.Printf("\n%s:\n", subPath)
+		}
+		if err := listPath(subPath, opts, ancestors); err != nil {
+			reportError(err)
+		}
+		delete(ancestors, key)
+	}
+	return nil
+}
+
+// enterDir adds the directory to the ancestors of the current -R walk, it returns
+// an error when it's already one of them, like a link cycle back to a parent with -L,
+// so its section is not printed again. The caller deletes the key once the directory
+// is listed, so a directory reached again by another branch is listed again like ls.
+func enterDir(path string, ancestors map[fileKey]bool) (fileKey, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileKey{}, err
+	}
+
+	key, ok := getFileKey(info.Sys())
+	if !ok {
+		return fileKey{}, nil
+	}
+	if ancestors[key] {
+		return fileKey{}, fmt.Errorf("%s: not listing already-listed directory", path)
+	}
+	ancestors[key] = true
+	return key, nil
+}
+
+// streamBatchSize is the number of entries read and printed at a time when streaming
+const streamBatchSize = 256
+
+// canStream returns true if the files can be printed while the directory is read,
+// which needs the order of the directory and an output without aligned columns.
+func canStream(opts options) bool {
+	if opts.sortKey != sortNone || opts.groupDirectoriesFirst || opts.numberRecords != 0 || opts.skip != 0 || opts.inode {
+		return false
+	}
+	if opts.json || opts.yaml || opts.csv || opts.markdown || opts.long || opts.commas || opts.summary || opts.groupByType {
+		return false
+	}
+	// the grid needs all the names, without a terminal it prints one per line
+	return opts.template != nil || opts.ndjson || opts.null || opts.onePerLine || !isTerminal()
+}
+
+// streamFiles prints the files of the given directory in batches as they're read,
+// so a huge directory shows up at once and isn't held in memory.
+// It returns the subdirectories listed, for the -R walk.
+func streamFiles(path string, opts options) ([]file, error) {
+	d, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	filter := newDirFilter(path, opts)
+	var dirs []file
+	for first := true; ; first = false {
+		start := time.Now()
+		entries, err := d.ReadDir(streamBatchSize)
+		track(&timings.read, start)
+		if first && opts.all && !opts.almostAll {
+			entries = append(dotEntries(path), entries...)
+		}
+		fs := filter.filter(entries)
+
+		if printErr := printList(fs, opts); printErr != nil {
+			return nil, printErr
+		}
+		for _, f := range fs {
+			if f.isDir {
+				dirs = append(dirs, f)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return dirs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readFiles returns the files of the given directory filtered and sorted
+// according to the options.
+// It returns an error if the directory can't be read.
+func readFiles(path string, opts options) ([]file, error) {
+	start := time.Now()
+	files, err := os.ReadDir(path)
+	track(&timings.read, start)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.all && !opts.almostAll {
+		files = append(dotEntries(path), files...)
+	}
+
+	fs := newDirFilter(path, opts).filter(files)
+
+	sortFiles(fs, opts)
+	return fs, nil
+}
+
+// namedEntry is a directory entry shown with another name, like . and ..
+type namedEntry struct {
+	os.DirEntry
+	name string
+}
+
+// Name returns the name of the entry.
+func (e namedEntry) Name() string {
+	return e.name
+}
+
+// dotEntries returns the . and .. entries of the directory, which os.ReadDir omits.
+// The .. of the root directory is the root itself.
+func dotEntries(path string) []os.DirEntry {
+	var entries []os.DirEntry
+	for _, name := range []string{".", ".."} {
+		info, err := os.Stat(filepath.Join(path, name))
+		if err != nil {
+			reportError(err)
+			continue
+		}
+		entries = append(entries, namedEntry{DirEntry: fs.FileInfoToDirEntry(info), name: name})
+	}
+	return entries
+}
+
+// isDotEntry returns true if the file is the . or .. entry of its directory,
+// which the recursive listings must not walk into.
+func isDotEntry(f file) bool {
+	return f.name == "." || f.name == ".."
+}
+
+// dirFilter filters the entries of a directory according to the options,
+// with the .gitignore rules and git statuses of the directory loaded once.
+type dirFilter struct {
+	path        string
+	opts        options
+	ignore      *gitIgnore
+	gitStatuses map[string]string
+}
+
+// newDirFilter returns the filter of the entries of the given directory.
+func newDirFilter(path string, opts options) dirFilter {
+	filter := dirFilter{path: path, opts: opts}
+	if opts.gitignore {
+		filter.ignore = loadGitIgnore(path)
+	}
+	if opts.git {
+		filter.gitStatuses = loadGitStatus(path)
+	}
+	return filter
+}
 
-	// order flags
-	hasOrderByTime := flag.Bool("t", false, "sort by time, oldest first")
-	hasOrderBySize := flag.Bool("s", false, "sort by file size, smallest first")
-	hasOrderReverse := flag.Bool("r", false, "reverse order while sorting")
+// filter returns the files of the entries that pass the filters of the options,
+// in the order of the entries. The files that can't be read are reported and left out.
+func (d dirFilter) filter(files []os.DirEntry) []file {
+	path, opts, ignore, gitStatuses := d.path, d.opts, d.ignore, d.gitStatuses
 
-	flag.Parse()
+	var entries []os.DirEntry
+	for _, f := range files {
+		if isHidden(f.Name(), path) && !opts.all {
+			continue
+		}
 
-	path := flag.Arg(0)
-	if path == "" {
-		path = "."
-	}
+		// we check the pattern given in the -p flag
+		if opts.match != nil && !opts.match(f.Name()) {
+			continue
+		}
 
-	files, err := os.ReadDir(path)
-	if err != nil {
-		fmt.Println(err)
-		return
+		// and drop the files matching the -I pattern
+		if opts.ignore != nil && opts.ignore.MatchString(f.Name()) {
+			continue
+		}
+
+		if ignore != nil && ignore.isIgnored(f.Name(), f.IsDir()) {
+			continue
+		}
+
+		entries = append(entries, f)
 	}
 
+	// the filters above only need the names, the rest need the information of the files
+	start := time.Now()
+	archivos := statFiles(path, entries, runtime.GOMAXPROCS(0), opts)
+	track(&timings.stat, start)
+
 	var fs []file
-	for _, f := range files {
-		isHidden := isHidden(f.Name(), path)
+	for _, archivo := range archivos {
+		archivo.gitStatus = gitStatuses[archivo.name]
 
-		if isHidden && !*flagAll {
+		if opts.audit && !isWorldWritable(archivo) {
 			continue
 		}
 
-		// we check the pattern given in the -p flag
-		if *flagPattern != "" {
-			isMatch, err := regexp.MatchString("(?i)"+*flagPattern, f.Name())
-			if err != nil {
-				panic(err)
-			}
-			if !isMatch {
-				continue
-			}
+		if (opts.dirsOnly && !archivo.isDir) || (opts.filesOnly && archivo.isDir) {
+			continue
 		}
 
-		archivo, err := getFile(f, isHidden)
-		if err != nil {
-			fmt.Println(err)
-			return
+		// directories are exempt from the size range, their size isn't their content
+		if !archivo.isDir && (archivo.size < opts.minSize || archivo.size > opts.maxSize) {
+			continue
+		}
+
+		if !opts.newerThan.IsZero() && !archivo.modificationTime.After(opts.newerThan) {
+			continue
+		}
+		if !opts.olderThan.IsZero() && !archivo.modificationTime.Before(opts.olderThan) {
+			continue
 		}
 
 		fs = append(fs, archivo)
 	}
+	return fs
+}
+
+// statFiles returns the files of the directory entries, read by a pool of
+// workers as the stat calls are slow on big or network directories.
+// The files keep the order of the entries. The ones that can't be read, like
+// a file removed since the directory was read, are reported and left out.
+func statFiles(path string, entries []os.DirEntry, workers int, opts options) []file {
+	fs := make([]file, len(entries))
+	errs := make([]error, len(entries))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(entries)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				f := entries[i]
+				fs[i], errs[i] = getFile(path, f, isHidden(f.Name(), path), opts)
+			}
+		}()
+	}
+
+	for i := range entries {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	read := fs[:0]
+	for i, err := range errs {
+		if err != nil {
+			reportError(err)
+			continue
+		}
+		read = append(read, fs[i])
+	}
+	return read
+}
+
+// limitFiles returns the first files up to the number of records given in -n,
+// or the last ones with --tail or a negative -n, after skipping the --skip ones.
+func limitFiles(fs []file, opts options) []file {
+	fs = fs[min(opts.skip, len(fs)):]
 
-	if !*hasOrderByTime && !*hasOrderBySize {
-		orderByName(fs, *hasOrderReverse)
+	numberRecords, tail := opts.numberRecords, opts.tail
+	if numberRecords < 0 {
+		numberRecords, tail = -numberRecords, true
+	}
+	if numberRecords == 0 || numberRecords > len(fs) {
+		numberRecords = len(fs)
 	}
 
-	if *hasOrderBySize && !*hasOrderByTime {
-		orderBySize(fs, *hasOrderReverse)
+	if tail {
+		return fs[len(fs)-numberRecords:]
 	}
+	return fs[:numberRecords]
+}
 
-	if *hasOrderByTime && !*hasOrderBySize {
-		orderByTime(fs, *hasOrderReverse)
+// sortFiles sorts the files by the key selected in the options.
+func sortFiles(fs []file, opts options) {
+	defer track(&timings.sort, time.Now())
+
+	switch opts.sortKey {
+	case sortTime:
+		orderByTime(fs, opts.orderReverse)
+	case sortAccessTime:
+		orderByAccessTime(fs, opts.orderReverse)
+	case sortChangeTime:
+		orderByChangeTime(fs, opts.orderReverse)
+	case sortSize:
+		orderBySize(fs, opts.orderReverse)
+	case sortExtension:
+		orderByExtension(fs, opts.orderReverse)
+	case sortVersion:
+		orderByNaturalName(fs, opts.orderReverse)
+	case sortNone:
+		// keep the order of the directory
+	case sortName:
+		orderByName(fs, opts.orderReverse)
+	default:
+		// the lists of keys like type,name
+		orderByKeys(fs, opts.sortKeys, opts.orderReverse)
 	}
 
-	if *flagNumberRecords == 0 || *flagNumberRecords > len(fs) {
-		*flagNumberRecords = len(fs)
+	if opts.groupDirectoriesFirst {
+		groupDirectoriesFirst(fs)
 	}
-	printList(fs, *flagNumberRecords)
 }
 
 func mySort[T constraints.Ordered](i, j T, isReverse bool) bool {
@@ -95,70 +979,522 @@ func mySort[T constraints.Ordered](i, j T, isReverse bool) bool {
 
 func orderByName(files []file, isReverse bool) {
 	sort.SliceStable(files, func(i, j int) bool {
-		return mySort(
-			strings.ToLower(files[i].name),
-			strings.ToLower(files[j].name),
-			isReverse,
-		)
+		return mySort(compareNames(files[i].name, files[j].name), 0, isReverse)
 	})
 }
 
 func orderBySize(files []file, isReverse bool) {
 	sort.SliceStable(files, func(i, j int) bool {
-		return mySort(
-			files[i].size,
-			files[j].size,
-			isReverse,
-		)
+		if files[i].size != files[j].size {
+			return mySort(files[i].size, files[j].size, isReverse)
+		}
+
+		// the order of the directory depends on the file system, the name makes it deterministic
+		return mySort(compareNames(files[i].name, files[j].name), 0, isReverse)
 	})
 }
 
 func orderByTime(files []file, isReverse bool) {
 	sort.SliceStable(files, func(i, j int) bool {
-		return mySort(
-			files[i].modificationTime.Unix(),
-			files[j].modificationTime.Unix(),
-			isReverse,
-		)
+		if files[i].modificationTime.UnixNano() != files[j].modificationTime.UnixNano() {
+			return mySort(files[i].modificationTime.UnixNano(), files[j].modificationTime.UnixNano(), isReverse)
+		}
+
+		return mySort(compareNames(files[i].name, files[j].name), 0, isReverse)
+	})
+}
+
+// orderByAccessTime sorts the files by their last access time.
+func orderByAccessTime(files []file, isReverse bool) {
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].accessTime.UnixNano() != files[j].accessTime.UnixNano() {
+			return mySort(files[i].accessTime.UnixNano(), files[j].accessTime.UnixNano(), isReverse)
+		}
+
+		return mySort(compareNames(files[i].name, files[j].name), 0, isReverse)
+	})
+}
+
+// orderByChangeTime sorts the files by their last status change time.
+func orderByChangeTime(files []file, isReverse bool) {
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].changeTime.UnixNano() != files[j].changeTime.UnixNano() {
+			return mySort(files[i].changeTime.UnixNano(), files[j].changeTime.UnixNano(), isReverse)
+		}
+
+		return mySort(compareNames(files[i].name, files[j].name), 0, isReverse)
+	})
+}
+
+// groupDirectoriesFirst moves the directories ahead of the rest of the files.
+// The partition is stable, so it keeps the order given by the active sort key
+// inside each group and it composes with any of the orderBy functions.
+func groupDirectoriesFirst(files []file) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].isDir && !files[j].isDir
+	})
+}
+
+// orderByExtension sorts the files by extension, breaking ties by name.
+// Files without an extension are grouped before the rest.
+func orderByExtension(files []file, isReverse bool) {
+	sort.SliceStable(files, func(i, j int) bool {
+		extI := strings.ToLower(extensionOf(files[i].name))
+		extJ := strings.ToLower(extensionOf(files[j].name))
+		if extI != extJ {
+			return mySort(extI, extJ, isReverse)
+		}
+
+		return mySort(compareNames(files[i].name, files[j].name), 0, isReverse)
+	})
+}
+
+// orderByNaturalName sorts the files by name comparing the numbers
+// within the names by their value, so file2 goes before file10.
+func orderByNaturalName(files []file, isReverse bool) {
+	sort.SliceStable(files, func(i, j int) bool {
+		nameI := strings.ToLower(files[i].name)
+		nameJ := strings.ToLower(files[j].name)
+		if isReverse {
+			return naturalLess(nameJ, nameI)
+		}
+		return naturalLess(nameI, nameJ)
+	})
+}
+
+// compareKey returns true if i goes before j like mySort, and true in tie
+// when both are equal so the next key decides.
+func compareKey[T constraints.Ordered](i, j T, isReverse bool) (less, tie bool) {
+	if i == j {
+		return false, true
+	}
+	return mySort(i, j, isReverse), false
+}
+
+// mapCompareBySortKey holds the comparisons of the keys of the --sort lists
+var mapCompareBySortKey = map[string]func(i, j file, isReverse bool) (less, tie bool){
+	sortName: func(i, j file, isReverse bool) (bool, bool) {
+		return compareKey(compareNames(i.name, j.name), 0, isReverse)
+	},
+	sortSize: func(i, j file, isReverse bool) (bool, bool) {
+		return compareKey(i.size, j.size, isReverse)
+	},
+	sortTime: func(i, j file, isReverse bool) (bool, bool) {
+		return compareKey(i.modificationTime.UnixNano(), j.modificationTime.UnixNano(), isReverse)
+	},
+	sortAccessTime: func(i, j file, isReverse bool) (bool, bool) {
+		return compareKey(i.accessTime.UnixNano(), j.accessTime.UnixNano(), isReverse)
+	},
+	sortChangeTime: func(i, j file, isReverse bool) (bool, bool) {
+		return compareKey(i.changeTime.UnixNano(), j.changeTime.UnixNano(), isReverse)
+	},
+	sortExtension: func(i, j file, isReverse bool) (bool, bool) {
+		return compareKey(strings.ToLower(extensionOf(i.name)), strings.ToLower(extensionOf(j.name)), isReverse)
+	},
+	sortVersion: func(i, j file, isReverse bool) (bool, bool) {
+		nameI, nameJ := strings.ToLower(i.name), strings.ToLower(j.name)
+		if nameI == nameJ {
+			return false, true
+		}
+		if isReverse {
+			return naturalLess(nameJ, nameI), false
+		}
+		return naturalLess(nameI, nameJ), false
+	},
+	// the types in the order of their declaration, directories after the regular files
+	sortType: func(i, j file, isReverse bool) (bool, bool) {
+		return compareKey(i.fileType, j.fileType, isReverse)
+	},
+}
+
+// orderByKeys sorts the files by the keys in turn, each next key breaks the ties
+// of the previous ones. The reverse applies to the whole comparison.
+func orderByKeys(files []file, keys []string, isReverse bool) {
+	sort.SliceStable(files, func(i, j int) bool {
+		for _, key := range keys {
+			if less, tie := mapCompareBySortKey[key](files[i], files[j], isReverse); !tie {
+				return less
+			}
+		}
+		return false
 	})
 }
 
-func printList(fs []file, numRegisters int) {
-	for _, f := range fs[:numRegisters] {
-		style := mapStyleByFileType[f.fileType]
+// naturalLess returns true if a goes before b in natural order, where the runs
+// of digits are compared by their numeric value and the rest byte by byte.
+// Equal numbers with leading zeros go after the shorter ones, so 1 < 01 < 2.
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		if !isDigit(a[0]) || !isDigit(b[0]) {
+			if a[0] != b[0] {
+				return a[0] < b[0]
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		numA, restA := splitDigits(a)
+		numB, restB := splitDigits(b)
+
+		// without leading zeros, a longer run is a bigger number
+		valueA := strings.TrimLeft(numA, "0")
+		valueB := strings.TrimLeft(numB, "0")
+		if len(valueA) != len(valueB) {
+			return len(valueA) < len(valueB)
+		}
+		if valueA != valueB {
+			return valueA < valueB
+		}
+		if len(numA) != len(numB) {
+			return len(numA) < len(numB)
+		}
+		a, b = restA, restB
+	}
+	return len(a) < len(b)
+}
+
+// splitDigits returns the leading run of digits of s and the rest of it.
+func splitDigits(s string) (digits, rest string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// isDigit returns true if the byte is an ASCII digit.
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// extensionOf returns the text after the last dot of the name, like gz for
+// archive.tar.gz. It's empty for names without a dot and for dotfiles like .bashrc,
+// and is shared by -X and --show-ext.
+func extensionOf(name string) string {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 {
+		return ""
+	}
+	return name[i+1:]
+}
+
+// printList prints the files in the format selected by the options.
+func printList(fs []file, opts options) error {
+	defer track(&timings.print, time.Now())
+
+	// the documents are printed once with the files of all the listings
+	if opts.document != nil {
+		*opts.document = append(*opts.document, fs...)
+		return nil
+	}
+
+	// the scripts get the flat list of the structured outputs and templates
+	if opts.groupByType && !opts.isStructured() && opts.template == nil {
+		return printGroups(fs, opts)
+	}
+	return printFormat(fs, opts)
+}
+
+// printFormat prints the files in the output format of the options,
+// followed by the --summary.
+func printFormat(fs []file, opts options) error {
+	switch {
+	case opts.template != nil:
+		return printTemplate(fs, opts.template)
+	// the structured outputs have no icons nor colors
+	case opts.json:
+		return printJSON(fs, opts)
+	case opts.ndjson:
+		return printNDJSON(fs, opts)
+	case opts.yaml:
+		return printYAML(fs, opts)
+	case opts.csv:
+		return printCSV(fs, opts)
+	case opts.null:
+		printNullSeparated(fs)
+	case opts.markdown:
+		printMarkdown(fs, opts)
+	case opts.long:
+		printLong(fs, opts)
+	case opts.onePerLine:
+		printNames(fs, opts)
+	case opts.commas:
+		printCommas(fs, opts)
+	default:
+		printGrid(fs, opts)
+	}
+
+	if opts.summary && !opts.isStructured() {
+		printSummary(fs, opts)
+	}
+	return nil
+}
+
+// printNullSeparated prints only the file names, each one followed by a NUL byte,
+// so they can be piped to xargs -0 even when they have spaces or newlines.
+func printNullSeparated(fs []file) {
+	for _, f := range fs {
+		fmt.Print(f.name + "\x00")
+	}
+}
+
+// printNames prints only the file names, one per line.
+func printNames(fs []file, opts options) {
+	inodes := formatInodes(fs, opts.inode)
+	for i, f := range fs {
+		fmt.Println(inodes[i] + formatGitColumn(f, opts.git) + formatName(f, opts))
+	}
+}
+
+// formatInodes returns the inode number column of the files, right-aligned
+// and followed by a space. The values are empty when show is false.
+func formatInodes(fs []file, show bool) []string {
+	inodes := make([]string, len(fs))
+	if !show {
+		return inodes
+	}
+
+	var width int
+	for i, f := range fs {
+		inodes[i] = strconv.FormatUint(f.inode, 10)
+		width = max(width, len(inodes[i]))
+	}
+	for i := range inodes {
+		inodes[i] = fmt.Sprintf("%*s ", width, inodes[i])
+	}
+	return inodes
+}
+
+// displayTime returns the time of the file selected by the --time flag,
+// it's zero when the birth time is not available.
+func displayTime(f file, field string) time.Time {
+	switch field {
+	case timeAccess:
+		return f.accessTime
+	case timeChange:
+		return f.changeTime
+	case timeBirth:
+		return f.birthTime
+	default:
+		return f.modificationTime
+	}
+}
+
+// formatGitColumn returns the git status column of the file when show is set.
+func formatGitColumn(f file, show bool) string {
+	if !show {
+		return ""
+	}
+	return formatGitStatus(f)
+}
+
+// printLong prints the files with their mode, hard links, owner, group, size and modification time,
+// or the columns chosen with --columns, inside a table with --table.
+// Like ls it starts with a total line, which sums only the sizes of the printed files
+// so it reflects the subset selected by -n.
+func printLong(fs []file, opts options) {
+	columns := opts.columns
+	if len(columns) == 0 {
+		columns = defaultColumns(opts)
+	}
+
+	if opts.table {
+		printTable(fs, columns, opts)
+		return
+	}
+
+	var total int64
+	for _, f := range fs {
+		total += f.size
+	}
+	fmt.Printf("total %s\n", formatSize(total, opts))
+
+	for _, line := range formatColumns(fs, columns, opts) {
+		fmt.Println(line)
+	}
+}
+
+// formatLinkTarget returns the " -> target" suffix of a symbolic link, or the
+// whole chain with --chain, marked when the target can't be reached.
+// It's empty for the rest of the files.
+func formatLinkTarget(f file) string {
+	if f.fileType != fileLink {
+		return ""
+	}
+	target := " -> " + f.linkTarget
+	if len(f.linkChain) > 0 {
+		target = " -> " + strings.Join(f.linkChain, " -> ")
+	}
+
+	switch {
+	case f.chainMarker != "":
+		return target + " " + f.chainMarker
+	case f.brokenLink:
+		return strings.TrimRight(target, " ") + " (broken)"
+	}
+	return target
+}
+
+// formatName returns the file name with the icon, color and symbol of its type.
+// With -F the symbol is replaced by the classify indicator of the type.
+func formatName(f file, opts options) string {
+	name := colorName(f, displayName(f, opts))
+	if opts.hyperlink {
+		name = hyperlink(name, f.path)
+	}
+	return fileIcon(f, opts) + name + fileSymbol(f, opts)
+}
+
+// displayName returns the name of the file as printed, truncated with an ellipsis
+// to the --max-name-width display width and then quoted with -Q or when needed on
+// a terminal, so the closing quote is kept.
+func displayName(f file, opts options) string {
+	name := f.name
+	// the need of quotes is decided on the whole name, before it's truncated
+	quote := opts.quote || (opts.quoteSpecial && strings.IndexFunc(name, needsQuoting) >= 0)
+	if opts.maxNameWidth > 0 {
+		name = runewidth.Truncate(name, opts.maxNameWidth, ellipsis)
+	}
+	if quote {
+		name = strconv.Quote(name)
+	}
+	return name
+}
+
+// fileIcon returns the icon of the file type in the --icons mode followed
+// by a space, empty in the none mode.
+func fileIcon(f file, opts options) string {
+	icon := mapIconsByMode[opts.icons][f.fileType]
+	if icon == "" {
+		return ""
+	}
+	return icon + " "
+}
+
+// colorName returns the name colored by the file type, unless the file has
+// the security relevant setuid, setgid or sticky bits or it's world-writable,
+// which have their own colors like the broken links. The LS_COLORS entries
+// take precedence when set.
+func colorName(f file, name string) string {
+	if lsColors != nil {
+		if code, ok := lsColors.code(f); ok {
+			return colorLS(name, code)
+		}
+	}
+	if f.brokenLink {
+		return red(name)
+	}
+	if hasSpecialBits(f) {
+		return specialBitsColor(name)
+	}
+	if isWorldWritable(f) {
+		return worldWritableColor(name)
+	}
+	return setColor(name, mapStyleByFileType[f.fileType].color)
+}
 
-		fmt.Printf("%s %s %s %8d %v %s %s%s\n",
-			f.mode, f.userName, f.groupName, f.size, f.modificationTime.Format(time.Stamp),
-			style.icon, setColor(f.name, style.color), style.symbol,
-		)
+// fileSymbol returns the symbol printed after the file name.
+func fileSymbol(f file, opts options) string {
+	if opts.classify {
+		return mapIndicatorByFileType[f.fileType]
 	}
+	return mapStyleByFileType[f.fileType].symbol
+}
+
+// needsQuoting returns true if the rune can't be printed as is in a name.
+func needsQuoting(r rune) bool {
+	return unicode.IsSpace(r) || !unicode.IsPrint(r) || r == '"'
 }
 
-// getFile returns a file object for the given file entry.
+// getFile returns a file object for the given file entry of the directory path.
 // It returns an error if it fails to retrieve information about the file.
-func getFile(f os.DirEntry, isHidden bool) (file, error) {
+// With -L the information of a symbolic link is taken from its target,
+// a broken link keeps its own information.
+func getFile(path string, f os.DirEntry, isHidden bool, opts options) (file, error) {
 	// info returns information about the named file.
 	info, err := f.Info()
 	if err != nil {
 		return file{}, fmt.Errorf("f.Info(): %v", err)
 	}
 
-	userName, groupName := fileinfo.GetUserAndGroup(info.Sys())
+	if opts.dereference && info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Stat(filepath.Join(path, f.Name()))
+		if err != nil {
+			reportError(fmt.Errorf("cannot follow link %s: %v", f.Name(), err))
+		} else {
+			info = target
+		}
+	}
+
+	key, _ := getFileKey(info.Sys())
+	uid, gid := getOwnerIDs(info.Sys())
+	accessTime, changeTime := getFileTimes(info)
+	userName, groupName := unknownOwner, unknownOwner
+	if opts.ownerNames {
+		userName, groupName = getUserAndGroup(info.Sys())
+	}
 
 	// create a new file object with the information retrieved from the file entry.
 	result := file{
 		name:             f.Name(),
-		isDir:            f.IsDir(),
+		path:             filepath.Join(path, f.Name()),
+		isDir:            info.IsDir(),
 		isHidden:         isHidden,
+		inode:            key.ino,
+		nlinks:           getLinkCount(info.Sys()),
+		uid:              uid,
+		gid:              gid,
 		userName:         userName,
 		groupName:        groupName,
 		size:             info.Size(),
 		modificationTime: info.ModTime(),
+		accessTime:       accessTime,
+		changeTime:       changeTime,
+		fileMode:         info.Mode(),
 		mode:             info.Mode().String(),
 	}
 
+	// the birth time needs an extra system call, so it's read only when shown
+	if opts.timeField == timeBirth || slices.Contains(opts.columns, columnBTime) {
+		result.birthTime, _ = getBirthTime(filepath.Join(path, f.Name()), info)
+	}
+
+	// with --magic the content of the regular files also decides their type
+	if opts.magic && info.Mode().IsRegular() {
+		result.contentType = detectContentType(filepath.Join(path, f.Name()))
+	}
+
+	// with --detect-scripts the regular files without the x bit starting with #!
+	// are executables too, the empty ones can't have a shebang
+	if opts.detectScripts && info.Mode().IsRegular() && info.Mode().Perm()&0o111 == 0 && info.Size() >= int64(len(shebang)) {
+		result.script = hasShebang(filepath.Join(path, f.Name()))
+	}
+
 	// set the file type based on the file properties.
 	setFile(&result)
+
+	// an unreadable link keeps an empty target and is shown as broken, like the
+	// links whose target can't be reached such as the circular ones
+	if result.fileType == fileLink {
+		if target, err := os.Readlink(filepath.Join(path, f.Name())); err == nil {
+			result.linkTarget = target
+		}
+		_, err := os.Stat(filepath.Join(path, f.Name()))
+		result.brokenLink = result.linkTarget == "" || err != nil
+
+		if opts.chain {
+			result.linkChain, result.chainMarker = resolveChain(filepath.Join(path, f.Name()))
+		}
+	}
+
+	if opts.count && result.isDir {
+		result.entries = countEntries(filepath.Join(path, f.Name()), opts.all)
+	}
+
+	// the walk is expensive, it's done only with --total-size by the stat workers
+	if opts.totalSize && result.isDir && !isDotEntry(result) {
+		result.size = dirSize(filepath.Join(path, f.Name()))
+	}
 	return result, nil
 }
 
@@ -169,12 +1505,30 @@ func setFile(f *file) {
 		f.fileType = fileLink
 	case f.isDir:
 		f.fileType = fileDirectory
+	case isFifo(*f):
+		f.fileType = fileFifo
+	case isSocket(*f):
+		f.fileType = fileSocket
+	case isCharDevice(*f):
+		f.fileType = fileCharDevice
+	case isDevice(*f):
+		f.fileType = fileDevice
 	case isExec(*f):
 		f.fileType = fileExecutable
 	case isCompress(*f):
 		f.fileType = fileCompress
 	case isImage(*f):
 		f.fileType = fileImage
+	case isVideo(*f):
+		f.fileType = fileVideo
+	// audio goes after video, some containers like .webm hold both
+	case isAudio(*f):
+		f.fileType = fileAudio
+	// source code goes first, its sniffed content is plain text as in many documents
+	case isSourceCode(*f):
+		f.fileType = fileSourceCode
+	case isDocument(*f):
+		f.fileType = fileDocument
 	default:
 		f.fileType = fileRegular
 	}
@@ -204,46 +1558,157 @@ func isLink(f file) bool {
 	return strings.HasPrefix(strings.ToUpper(f.mode), "L")
 }
 
-// isExec returns true if the file is executable.
-// On Windows, it checks if the file name ends with ".exe".
+// hasSpecialBits returns true if the file has the setuid, setgid or sticky bit.
+func hasSpecialBits(f file) bool {
+	return f.fileMode&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky) != 0
+}
+
+// isWorldWritable returns true if anyone can write the file, a common misconfiguration.
+// The permissions of the symbolic links are meaningless so they are never world-writable.
+func isWorldWritable(f file) bool {
+	return f.fileMode&os.ModeSymlink == 0 && f.fileMode.Perm()&0o002 != 0
+}
+
+// isFifo returns true if the file is a named pipe.
+func isFifo(f file) bool {
+	return f.fileMode&os.ModeNamedPipe != 0
+}
+
+// isSocket returns true if the file is an unix domain socket.
+func isSocket(f file) bool {
+	return f.fileMode&os.ModeSocket != 0
+}
+
+// isCharDevice returns true if the file is a character device.
+func isCharDevice(f file) bool {
+	return f.fileMode&os.ModeCharDevice != 0
+}
+
+// isDevice returns true if the file is a block device,
+// character devices also carry the device bit so they go first.
+func isDevice(f file) bool {
+	return f.fileMode&os.ModeDevice != 0
+}
+
+// isExec returns true if the file is executable or a script found by --detect-scripts.
+// On Windows, it checks if the file name ends with an executable extension like ".exe".
 // On other systems, it checks if the file mode contains the "x" permission.
 func isExec(f file) bool {
+	if f.script {
+		return true
+	}
 	if runtime.GOOS == Windows {
-		return strings.HasSuffix(f.name, exe)
+		var suffix = []string{exe, bat, cmd, com, ps1, msi}
+
+		// extensions are matched case insensitively, so PROGRAM.EXE is executable
+		name := strings.ToLower(f.name)
+		for _, s := range suffix {
+			if strings.HasSuffix(name, s) {
+				return true
+			}
+		}
+		return false
 	}
 	return strings.Contains(f.mode, "x")
 }
 
 // isCompress returns true if the file is compressed.
 func isCompress(f file) bool {
-	var suffix = []string{deb, zip, gz, tar, rar}
+	if slices.Contains(compressContentTypes, f.contentType) {
+		return true
+	}
+
+	var suffix = []string{deb, zip, gz, tar, rar, sevenZip, bz2, xz, zst, lz4, tgz, tbz}
 
+	// extensions are matched case insensitively, so ARCHIVE.ZIP is compressed
+	name := strings.ToLower(f.name)
 	for _, s := range suffix {
-		if strings.HasSuffix(f.name, s) {
+		if strings.HasSuffix(name, s) {
 			return true
 		}
 	}
-	return false
+
+	// multi-part extensions like archive.tar.gz or archive.tar.lzma
+	return strings.Contains(name, tar+".")
 }
 
 // isImage returns true if the file is an image.
 func isImage(f file) bool {
-	var suffix = []string{png, jpg, gif}
+	if strings.HasPrefix(f.contentType, "image/") {
+		return true
+	}
+
+	var suffix = []string{png, jpg, jpeg, gif, webp, bmp, tiff, tif, svg, heic, avif, ico}
 
+	name := strings.ToLower(f.name)
 	for _, s := range suffix {
-		if strings.HasSuffix(f.name, s) {
+		if strings.HasSuffix(name, s) {
 			return true
 		}
 	}
 	return false
 }
 
-func isHidden(filename, basePath string) bool {
-	filePath := filename
+// isVideo returns true if the file is a video.
+func isVideo(f file) bool {
+	if strings.HasPrefix(f.contentType, "video/") {
+		return true
+	}
 
-	if runtime.GOOS == Windows {
-		filePath = path.Join(basePath, filename)
+	var suffix = []string{mp4, mkv, mov, avi, webm, flv, wmv}
+
+	name := strings.ToLower(f.name)
+	for _, s := range suffix {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAudio returns true if the file is an audio.
+func isAudio(f file) bool {
+	if strings.HasPrefix(f.contentType, "audio/") || f.contentType == "application/ogg" {
+		return true
+	}
+
+	var suffix = []string{mp3, flac, wav, ogg, m4a, aac, opus}
+
+	name := strings.ToLower(f.name)
+	for _, s := range suffix {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDocument returns true if the file is a document.
+func isDocument(f file) bool {
+	if f.contentType == "application/pdf" {
+		return true
+	}
+
+	var suffix = []string{pdf, doc, docx, md, txt, odt}
+
+	name := strings.ToLower(f.name)
+	for _, s := range suffix {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
 	}
+	return false
+}
 
-	return fileinfo.IsHidden(filePath)
+// isSourceCode returns true if the file is source code.
+func isSourceCode(f file) bool {
+	var suffix = []string{goSrc, pySrc, jsSrc, rsSrc, cSrc, javaSrc}
+
+	name := strings.ToLower(f.name)
+	for _, s := range suffix {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	return false
 }
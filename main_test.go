@@ -0,0 +1,432 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// names returns the names of the files in their order.
+func names(fs []file) []string {
+	result := make([]string, len(fs))
+	for i, f := range fs {
+		result[i] = f.name
+	}
+	return result
+}
+
+func TestSortTimeAndSizeTogether(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	files := []file{
+		{name: "c", size: 10, modificationTime: base},
+		{name: "a", size: 30, modificationTime: base.Add(2 * time.Hour)},
+		{name: "b", size: 20, modificationTime: base.Add(time.Hour)},
+		{name: "d", size: 10, modificationTime: base.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{args: []string{"-t", "-s"}, want: []string{"c", "d", "b", "a"}},
+		{args: []string{"-s", "-t"}, want: []string{"c", "b", "d", "a"}},
+	}
+	for _, tt := range tests {
+		var opts options
+		if err := newSortFlags(&opts).Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		if err := resolveSortKey(&opts); err != nil {
+			t.Fatal(err)
+		}
+
+		// the order must not depend on the order of the directory
+		reversed := slices.Clone(files)
+		slices.Reverse(reversed)
+		for _, fs := range [][]file{slices.Clone(files), reversed} {
+			sortFiles(fs, opts)
+			if got := names(fs); !slices.Equal(got, tt.want) {
+				t.Errorf("%v: got %v, want %v", tt.args, got, tt.want)
+			}
+		}
+	}
+}
+
+// testOptions returns the options of the flag defaults, without icons nor colors
+// so the output is plain text.
+func testOptions() options {
+	color.NoColor = true
+	return options{
+		timeField:   timeModification,
+		timeStyle:   "default",
+		icons:       iconsNone,
+		tableBorder: tableBox,
+		sortKey:     sortName,
+	}
+}
+
+// captureStdout returns what the function prints to stdout.
+func captureStdout(t *testing.T, print func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		output <- string(b)
+	}()
+
+	print()
+	w.Close()
+	return <-output
+}
+
+// testFiles returns a regular file and a directory with fixed metadata.
+func testFiles() []file {
+	modified := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+	return []file{
+		{name: "main.go", fileType: fileSourceCode, nlinks: 1, userName: "ana", groupName: "staff", size: 1234, modificationTime: modified, mode: "-rw-r--r--"},
+		{name: "docs", fileType: fileDirectory, isDir: true, nlinks: 2, userName: "ana", groupName: "staff", size: 4096, modificationTime: modified, mode: "drwxr-xr-x"},
+	}
+}
+
+func TestPrintListDefaultIsNameOnly(t *testing.T) {
+	output := captureStdout(t, func() {
+		if err := printList(testFiles(), testOptions()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// stdout is a pipe here, so the grid prints one name per line
+	if want := "main.go\ndocs/\n"; output != want {
+		t.Errorf("got %q, want %q", output, want)
+	}
+}
+
+func TestGridLayout(t *testing.T) {
+	tests := []struct {
+		widths               []int
+		termWidth            int
+		rows, cols, colWidth int
+	}{
+		{widths: nil, termWidth: 80},
+		{widths: []int{5, 5, 5}, termWidth: 80, rows: 1, cols: 3, colWidth: 7},
+		// 3 columns of 7 need 19 characters, the last one without the gap
+		{widths: []int{5, 5, 5, 5, 5}, termWidth: 19, rows: 2, cols: 3, colWidth: 7},
+		{widths: []int{5, 5, 5, 5, 5}, termWidth: 18, rows: 3, cols: 2, colWidth: 7},
+		{widths: []int{100}, termWidth: 80, rows: 1, cols: 1, colWidth: 102},
+	}
+	for _, tt := range tests {
+		rows, cols, colWidth := gridLayout(tt.widths, tt.termWidth)
+		if rows != tt.rows || cols != tt.cols || colWidth != tt.colWidth {
+			t.Errorf("gridLayout(%v, %d) = %d, %d, %d, want %d, %d, %d",
+				tt.widths, tt.termWidth, rows, cols, colWidth, tt.rows, tt.cols, tt.colWidth)
+		}
+	}
+}
+
+func TestPrintListLong(t *testing.T) {
+	opts := testOptions()
+	opts.long = true
+	output := captureStdout(t, func() {
+		if err := printList(testFiles(), opts); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := "total 5330\n" +
+		"-rw-r--r-- 1 ana staff 1234 Mar  5 14:30:00 main.go\n" +
+		"drwxr-xr-x 2 ana staff 4096 Mar  5 14:30:00 docs/\n"
+	if output != want {
+		t.Errorf("got\n%s\nwant\n%s", output, want)
+	}
+}
+
+func TestPrintLongTotal(t *testing.T) {
+	fs := []file{
+		{name: "a", size: 100, mode: "-rw-r--r--"},
+		{name: "b", size: 20, mode: "-rw-r--r--"},
+		{name: "c", size: 3, mode: "-rw-r--r--"},
+	}
+
+	tests := []struct {
+		numberRecords int
+		want          string
+	}{
+		{numberRecords: 0, want: "total 123"},
+		// the total only counts the files kept by -n
+		{numberRecords: 2, want: "total 120"},
+		{numberRecords: -1, want: "total 3"},
+	}
+	for _, tt := range tests {
+		opts := testOptions()
+		opts.long = true
+		opts.numberRecords = tt.numberRecords
+		output := captureStdout(t, func() {
+			printLong(limitFiles(fs, opts), opts)
+		})
+
+		if total, _, _ := strings.Cut(output, "\n"); total != tt.want {
+			t.Errorf("-n %d: got %q, want %q", tt.numberRecords, total, tt.want)
+		}
+	}
+}
+
+// filesNamed returns regular files with the given names.
+func filesNamed(names ...string) []file {
+	fs := make([]file, len(names))
+	for i, name := range names {
+		fs[i] = file{name: name}
+	}
+	return fs
+}
+
+func TestOrderByExtension(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []string
+		isReverse bool
+		want      []string
+	}{
+		{
+			name:  "mixed extensions",
+			files: []string{"b.txt", "a.go", "c.go", "a.txt", "z.c"},
+			want:  []string{"z.c", "a.go", "c.go", "a.txt", "b.txt"},
+		},
+		{
+			name:  "no extension first",
+			files: []string{"main.go", "Makefile", "README", "x.md"},
+			want:  []string{"Makefile", "README", "main.go", "x.md"},
+		},
+		{
+			// the dotfiles have no extension, .bashrc is not a bashrc extension
+			name:  "dotfiles",
+			files: []string{"z.sh", ".bashrc", ".config.yml", "LICENSE"},
+			want:  []string{".bashrc", "LICENSE", "z.sh", ".config.yml"},
+		},
+		{
+			name:  "last extension",
+			files: []string{"a.tar.gz", "b.tar", "c.gz"},
+			want:  []string{"a.tar.gz", "c.gz", "b.tar"},
+		},
+		{
+			name:  "case insensitive",
+			files: []string{"b.GO", "a.go", "c.Txt"},
+			want:  []string{"a.go", "b.GO", "c.Txt"},
+		},
+		{
+			name:      "reverse",
+			files:     []string{"b.txt", "a.go", "README", "a.txt"},
+			isReverse: true,
+			want:      []string{"b.txt", "a.txt", "a.go", "README"},
+		},
+	}
+	for _, tt := range tests {
+		fs := filesNamed(tt.files...)
+		orderByExtension(fs, tt.isReverse)
+		if got := names(fs); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsImage(t *testing.T) {
+	for _, ext := range []string{png, jpg, jpeg, gif, webp, bmp, tiff, tif, svg, heic, avif, ico} {
+		f := file{name: "picture" + ext, mode: "-rw-r--r--"}
+		if !isImage(f) {
+			t.Errorf("isImage(%q) = false, want true", f.name)
+		}
+
+		setFile(&f)
+		if f.fileType != fileImage {
+			t.Errorf("%q has the file type %s, want image", f.name, mapNameByFileType[f.fileType])
+		}
+	}
+
+	for _, name := range []string{"notes.txt", "png", "image.png.txt"} {
+		if isImage(file{name: name}) {
+			t.Errorf("isImage(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestUppercaseExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType int
+	}{
+		{name: "PHOTO.PNG", fileType: fileImage},
+		{name: "Photo.Jpeg", fileType: fileImage},
+		{name: "ARCHIVE.ZIP", fileType: fileCompress},
+		{name: "BACKUP.TAR.GZ", fileType: fileCompress},
+		{name: "Backup.Tar.Lzma", fileType: fileCompress},
+	}
+	for _, tt := range tests {
+		f := file{name: tt.name, mode: "-rw-r--r--"}
+		setFile(&f)
+		if f.fileType != tt.fileType {
+			t.Errorf("%q has the file type %s, want %s",
+				tt.name, mapNameByFileType[f.fileType], mapNameByFileType[tt.fileType])
+		}
+	}
+
+	if !isImage(file{name: "PHOTO.PNG"}) {
+		t.Error(`isImage("PHOTO.PNG") = false, want true`)
+	}
+	if !isCompress(file{name: "ARCHIVE.ZIP"}) {
+		t.Error(`isCompress("ARCHIVE.ZIP") = false, want true`)
+	}
+}
+
+func BenchmarkStatFiles(b *testing.B) {
+	dir := b.TempDir()
+	for i := 0; i < 5000; i++ {
+		if err := os.WriteFile(filepath.Join(dir, "file"+strconv.Itoa(i)), nil, 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	opts := testOptions()
+
+	// the pool of the listings against a single worker, the stat calls one by one
+	for _, workers := range slices.Compact([]int{1, runtime.GOMAXPROCS(0)}) {
+		b.Run(strconv.Itoa(workers)+"-workers", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if fs := statFiles(dir, entries, workers, opts); len(fs) != len(entries) {
+					b.Fatalf("got %d files, want %d", len(fs), len(entries))
+				}
+			}
+		})
+	}
+}
+
+func TestStatFilesLeavesOutTheVanishedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// b is removed between the ReadDir and the stat, like a build output
+	if err := os.Remove(filepath.Join(dir, "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	exitStatus = exitOK
+	fs := statFiles(dir, entries, 1, testOptions())
+	if got := names(fs); !slices.Equal(got, []string{"a", "c"}) {
+		t.Errorf("got %v, want [a c]", got)
+	}
+	if exitStatus != exitFailure {
+		t.Errorf("got the exit status %d, want %d", exitStatus, exitFailure)
+	}
+	exitStatus = exitOK
+}
+
+func TestSortKeysBreakTiesByName(t *testing.T) {
+	for _, sortKey := range []string{sortType, "size,type", "type,size"} {
+		opts := testOptions()
+		opts.sortKey = sortKey
+		if err := resolveSortKey(&opts); err != nil {
+			t.Fatal(err)
+		}
+
+		// the same type and size, only the name sets the order
+		fs := filesNamed("c", "a", "d", "b")
+		sortFiles(fs, opts)
+		if got, want := names(fs), []string{"a", "b", "c", "d"}; !slices.Equal(got, want) {
+			t.Errorf("--sort %s: got %v, want %v", sortKey, got, want)
+		}
+	}
+}
+
+func TestTieBreaksFollowTheCollation(t *testing.T) {
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+	setupCollation(false)
+	defer func() { nameCollator = nil }()
+
+	want := []string{"a", "ä", "b"}
+	orders := map[string]func([]file, bool){
+		"name":      orderByName,
+		"size":      orderBySize,
+		"time":      orderByTime,
+		"atime":     orderByAccessTime,
+		"ctime":     orderByChangeTime,
+		"extension": orderByExtension,
+	}
+	for name, order := range orders {
+		fs := filesNamed("b", "ä", "a")
+		order(fs, false)
+		if got := names(fs); !slices.Equal(got, want) {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "img2.png", b: "img10.png", want: true},
+		{a: "img10.png", b: "img2.png", want: false},
+		{a: "v1.09", b: "v1.10", want: true},
+		{a: "v1.10", b: "v1.09", want: false},
+		// the equal numbers with leading zeros go after the shorter ones
+		{a: "1", b: "01", want: true},
+		{a: "01", b: "2", want: true},
+		{a: "01", b: "1", want: false},
+		{a: "file", b: "file1", want: true},
+		{a: "same", b: "same", want: false},
+	}
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestOrderByNaturalName(t *testing.T) {
+	tests := []struct {
+		files     []string
+		isReverse bool
+		want      []string
+	}{
+		{files: []string{"img10.png", "img2.png", "img1.png"}, want: []string{"img1.png", "img2.png", "img10.png"}},
+		{files: []string{"v1.10", "v1.09", "v1.9"}, want: []string{"v1.9", "v1.09", "v1.10"}},
+		{files: []string{"2", "01", "1"}, want: []string{"1", "01", "2"}},
+		{files: []string{"IMG2.png", "img10.png", "img1.png"}, want: []string{"img1.png", "IMG2.png", "img10.png"}},
+		{files: []string{"img1.png", "img10.png", "img2.png"}, isReverse: true, want: []string{"img10.png", "img2.png", "img1.png"}},
+	}
+	for _, tt := range tests {
+		fs := filesNamed(tt.files...)
+		orderByNaturalName(fs, tt.isReverse)
+		if got := names(fs); !slices.Equal(got, tt.want) {
+			t.Errorf("%v reverse %v: got %v, want %v", tt.files, tt.isReverse, got, tt.want)
+		}
+	}
+}
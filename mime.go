@@ -0,0 +1,66 @@
+package main
+
+import (
+	"mime"
+	"strings"
+	"sync"
+)
+
+// defaultMIMEType is the MIME type of the regular files of unknown type
+const defaultMIMEType = "application/octet-stream"
+
+// mapMIMETypeByFileType holds the pseudo MIME types of the files without
+// content, like the shared-mime-info ones used by the file managers
+var mapMIMETypeByFileType = map[int]string{
+	fileDirectory:  "inode/directory",
+	fileLink:       "inode/symlink",
+	fileFifo:       "inode/fifo",
+	fileSocket:     "inode/socket",
+	fileDevice:     "inode/blockdevice",
+	fileCharDevice: "inode/chardevice",
+}
+
+// mimeTypes caches the MIME types of the extensions, as many files share them
+// and the workers look them up concurrently
+var mimeTypes sync.Map
+
+// mimeType returns the MIME type of the file by its extension, with --magic
+// the detected content type takes precedence unless it's a generic one.
+func mimeType(f file) string {
+	if pseudo, ok := mapMIMETypeByFileType[f.fileType]; ok {
+		return pseudo
+	}
+
+	detected := mediaType(f.contentType)
+	if detected != "" && detected != defaultMIMEType && detected != "text/plain" {
+		return detected
+	}
+
+	if mimeType := extensionMIMEType(strings.ToLower(extensionOf(f.name))); mimeType != "" {
+		return mimeType
+	}
+	if detected != "" {
+		return detected
+	}
+	return defaultMIMEType
+}
+
+// extensionMIMEType returns the MIME type of the extension without its
+// parameters, empty if it's not known.
+func extensionMIMEType(ext string) string {
+	if ext == "" {
+		return ""
+	}
+	if mimeType, ok := mimeTypes.Load(ext); ok {
+		return mimeType.(string)
+	}
+
+	mimeType, _ := mimeTypes.LoadOrStore(ext, mediaType(mime.TypeByExtension("."+ext)))
+	return mimeType.(string)
+}
+
+// mediaType returns the MIME type without parameters like "; charset=utf-8".
+func mediaType(mimeType string) string {
+	mediaType, _, _ := strings.Cut(mimeType, ";")
+	return strings.TrimSpace(mediaType)
+}
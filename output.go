@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileJSON is the marshalable view of a file used by the JSON and YAML outputs
+type fileJSON struct {
+	Name             string `json:"name" yaml:"name"`
+	Path             string `json:"path,omitempty" yaml:"path,omitempty"`
+	Size             int64  `json:"size" yaml:"size"`
+	Mode             string `json:"mode" yaml:"mode"`
+	ModificationTime string `json:"modificationTime" yaml:"modificationTime"`
+	IsDir            bool   `json:"isDir" yaml:"isDir"`
+	IsHidden         bool   `json:"isHidden" yaml:"isHidden"`
+	FileType         string `json:"fileType" yaml:"fileType"`
+	*fileJSONLong    `yaml:",inline"`
+}
+
+// fileJSONLong holds the metadata added to the JSON view with --long,
+// it's nil by default to keep the output compact.
+type fileJSONLong struct {
+	OctalMode  string `json:"octalMode" yaml:"octalMode"`
+	Inode      uint64 `json:"inode" yaml:"inode"`
+	Links      uint64 `json:"nlinks" yaml:"nlinks"`
+	UID        uint32 `json:"uid" yaml:"uid"`
+	GID        uint32 `json:"gid" yaml:"gid"`
+	Owner      string `json:"owner" yaml:"owner"`
+	Group      string `json:"group" yaml:"group"`
+	AccessTime string `json:"accessTime" yaml:"accessTime"`
+	ChangeTime string `json:"changeTime" yaml:"changeTime"`
+	LinkTarget string `json:"linkTarget,omitempty" yaml:"linkTarget,omitempty"`
+}
+
+// newFileJSON returns the JSON view of the given file, with all its metadata with --long
+// and its path from the argument like dir/sub/file in the recursive and multi-path runs.
+func newFileJSON(f file, opts options) fileJSON {
+	view := fileJSON{
+		Name:             f.name,
+		Size:             f.size,
+		Mode:             f.mode,
+		ModificationTime: f.modificationTime.Format(time.RFC3339),
+		IsDir:            f.isDir,
+		IsHidden:         f.isHidden,
+		FileType:         mapNameByFileType[f.fileType],
+	}
+
+	if opts.showPaths {
+		view.Path = f.path
+	}
+
+	if opts.long {
+		view.fileJSONLong = &fileJSONLong{
+			OctalMode:  fmt.Sprintf("%04o", f.fileMode.Perm()),
+			Inode:      f.inode,
+			Links:      f.nlinks,
+			UID:        f.uid,
+			GID:        f.gid,
+			Owner:      f.userName,
+			Group:      f.groupName,
+			AccessTime: f.accessTime.Format(time.RFC3339),
+			ChangeTime: f.changeTime.Format(time.RFC3339),
+			LinkTarget: f.linkTarget,
+		}
+	}
+	return view
+}
+
+// printJSON writes the files to stdout as a JSON array, a single one for the whole run.
+func printJSON(fs []file, opts options) error {
+	views := make([]fileJSON, 0, len(fs))
+	for _, f := range fs {
+		views = append(views, newFileJSON(f, opts))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(views)
+}
+
+// printNDJSON writes the files to stdout as newline delimited JSON, an object
+// per line without the surrounding array so they can be read as a stream.
+func printNDJSON(fs []file, opts options) error {
+	encoder := json.NewEncoder(os.Stdout)
+	for _, f := range fs {
+		if err := encoder.Encode(newFileJSON(f, opts)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printYAML writes the files to stdout as a YAML sequence of mappings like the JSON output.
+func printYAML(fs []file, opts options) error {
+	views := make([]fileJSON, 0, len(fs))
+	for _, f := range fs {
+		views = append(views, newFileJSON(f, opts))
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(views); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// printCSV writes the files to stdout as comma separated values with a header line.
+// In the recursive and multi-path runs the first column is the path of the file
+// from its argument like dir/sub/file, as the names alone repeat.
+func printCSV(fs []file, opts options) error {
+	first := "name"
+	if opts.showPaths {
+		first = "path"
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write([]string{first, "size", "mode", "modtime", "type", "owner", "group"}); err != nil {
+		return err
+	}
+
+	for _, f := range fs {
+		name := f.name
+		if opts.showPaths {
+			name = f.path
+		}
+
+		record := []string{
+			name,
+			strconv.FormatInt(f.size, 10),
+			f.mode,
+			f.modificationTime.Format(time.RFC3339),
+			mapNameByFileType[f.fileType],
+			f.userName,
+			f.groupName,
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// printMarkdown prints the files as a GitHub flavored Markdown table
+// with the name, human readable size, type and modification time.
+func printMarkdown(fs []file, opts options) {
+	modificationTime := timeColumn(timeModification)
+
+	fmt.Println("| Name | Size | Type | Modified |")
+	fmt.Println("| --- | ---: | --- | --- |")
+	for _, f := range fs {
+		fmt.Printf("| %s | %s | %s | %s |\n",
+			escapeMarkdown(f.name), humanizeSize(f.size, opts.sizeBase()), mapNameByFileType[f.fileType], modificationTime(f, opts))
+	}
+}
+
+// escapeMarkdown escapes the characters of the name that would break a Markdown table cell.
+func escapeMarkdown(name string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ").Replace(name)
+}
+
+// printSummary prints a footer with the number of files of each type
+// and their total size, like "5 entries: 2 directory, 3 source, total 12K".
+func printSummary(fs []file, opts options) {
+	counts := map[int]int{}
+	var total int64
+	for _, f := range fs {
+		counts[f.fileType]++
+		total += f.size
+	}
+
+	var parts []string
+	for fileType := fileRegular; fileType <= fileCharDevice; fileType++ {
+		if counts[fileType] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[fileType], mapNameByFileType[fileType]))
+		}
+	}
+	parts = append(parts, "total "+formatSize(total, opts))
+
+	fmt.Printf("\n%d entries: %s\n", len(fs), strings.Join(parts, ", "))
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestPrintCSVPaths(t *testing.T) {
+	fs := []file{
+		{name: "a", path: "d1/a", size: 1, mode: "-rw-r--r--", userName: "ana", groupName: "staff"},
+		{name: "a", path: "d1/sub/a", size: 2, mode: "-rw-r--r--", userName: "ana", groupName: "staff"},
+	}
+
+	opts := testOptions()
+	opts.csv, opts.showPaths = true, true
+	output := captureStdout(t, func() {
+		if err := printCSV(fs, opts); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := "path,size,mode,modtime,type,owner,group\n" +
+		"d1/a,1,-rw-r--r--,0001-01-01T00:00:00Z,regular,ana,staff\n" +
+		"d1/sub/a,2,-rw-r--r--,0001-01-01T00:00:00Z,regular,ana,staff\n"
+	if output != want {
+		t.Errorf("got\n%s\nwant\n%s", output, want)
+	}
+}
+
+func TestNewFileJSONPath(t *testing.T) {
+	f := file{name: "a", path: "d1/sub/a"}
+	opts := testOptions()
+	if view := newFileJSON(f, opts); view.Path != "" {
+		t.Errorf("got the path %q in a single listing, want none", view.Path)
+	}
+
+	opts.showPaths = true
+	if view := newFileJSON(f, opts); view.Path != "d1/sub/a" {
+		t.Errorf("got the path %q, want d1/sub/a", view.Path)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package main
+
+// getOwnerIDs returns always zero because this system has no known
+// uid and gid in its stat information.
+func getOwnerIDs(infoSys any) (uid, gid uint32) {
+	return
+}
+
+// getUserAndGroup returns placeholders because this system has no known
+// owner and group in its stat information.
+func getUserAndGroup(infoSys any) (userName, groupName string) {
+	return unknownOwner, unknownOwner
+}
@@ -0,0 +1,91 @@
+//go:build unix
+
+package main
+
+import (
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// userNames and groupNames cache the resolved names by id for the whole run,
+// so a tree full of files owned by the same users only does one lookup each.
+var (
+	userNames  = &nameCache{lookup: lookupUserName}
+	groupNames = &nameCache{lookup: lookupGroupName}
+)
+
+// nameCache memoizes the names of the user or group ids, safe for the
+// concurrent stat workers. The ids that can't be resolved are cached too,
+// with their number as name, so a failing lookup isn't repeated.
+type nameCache struct {
+	names  sync.Map
+	lookup func(id string) (string, error)
+}
+
+// name returns the name of the id, or the id itself when it can't be resolved.
+func (c *nameCache) name(id uint32) string {
+	if name, ok := c.names.Load(id); ok {
+		return name.(string)
+	}
+
+	number := strconv.FormatUint(uint64(id), 10)
+	name, err := c.lookup(number)
+	if err != nil {
+		name = number
+	}
+
+	// two workers may resolve the same id, both get the first stored name
+	stored, _ := c.names.LoadOrStore(id, name)
+	return stored.(string)
+}
+
+// getOwnerIDs returns the numeric uid and gid of an unix file.
+func getOwnerIDs(infoSys any) (uid, gid uint32) {
+	stat, ok := infoSys.(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+
+	return stat.Uid, stat.Gid
+}
+
+// getUserAndGroup returns the owner and group names of a unix file.
+// It falls back to the numeric id when the id can't be resolved.
+func getUserAndGroup(infoSys any) (userName, groupName string) {
+	stat, ok := infoSys.(*syscall.Stat_t)
+	if !ok {
+		return unknownOwner, unknownOwner
+	}
+
+	return lookupUser(stat.Uid), lookupGroup(stat.Gid)
+}
+
+// lookupUser returns the user name for the given uid.
+func lookupUser(uid uint32) string {
+	return userNames.name(uid)
+}
+
+// lookupGroup returns the group name for the given gid.
+func lookupGroup(gid uint32) string {
+	return groupNames.name(gid)
+}
+
+// lookupUserName returns the name of the user of the numeric id.
+func lookupUserName(id string) (string, error) {
+	u, err := user.LookupId(id)
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+// lookupGroupName returns the name of the group of the numeric id.
+func lookupGroupName(id string) (string, error) {
+	g, err := user.LookupGroupId(id)
+	if err != nil {
+		return "", err
+	}
+	return g.Name, nil
+}
@@ -0,0 +1,13 @@
+package main
+
+// getOwnerIDs returns always zero because windows files
+// don't carry an unix uid and gid.
+func getOwnerIDs(infoSys any) (uid, gid uint32) {
+	return
+}
+
+// getUserAndGroup returns placeholders because windows files
+// don't carry an unix owner and group.
+func getUserAndGroup(infoSys any) (userName, groupName string) {
+	return unknownOwner, unknownOwner
+}
@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package main
+
+// getFileKey returns always false because this system has no known
+// inode number in its stat information.
+func getFileKey(infoSys any) (fileKey, bool) {
+	return fileKey{}, false
+}
+
+// getLinkCount returns always 1 because this system has no known
+// number of hard links in its stat information.
+func getLinkCount(infoSys any) uint64 {
+	return 1
+}
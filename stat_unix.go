@@ -0,0 +1,25 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// getFileKey returns the device and inode numbers of an unix file.
+func getFileKey(infoSys any) (fileKey, bool) {
+	stat, ok := infoSys.(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, false
+	}
+
+	return fileKey{dev: uint64(stat.Dev), ino: stat.Ino}, true
+}
+
+// getLinkCount returns the number of hard links of an unix file.
+func getLinkCount(infoSys any) uint64 {
+	stat, ok := infoSys.(*syscall.Stat_t)
+	if !ok {
+		return 1
+	}
+
+	return uint64(stat.Nlink)
+}
@@ -0,0 +1,13 @@
+package main
+
+// getFileKey returns always false because windows files
+// don't carry an inode number in their stat information.
+func getFileKey(infoSys any) (fileKey, bool) {
+	return fileKey{}, false
+}
+
+// getLinkCount returns always 1 because windows stat information
+// doesn't carry the number of hard links.
+func getLinkCount(infoSys any) uint64 {
+	return 1
+}
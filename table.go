@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// values of the --table-border flag
+const (
+	tableBox   = "box"
+	tableASCII = "ascii"
+)
+
+// tableBorder holds the characters of the borders of a table
+type tableBorder struct {
+	horizontal, vertical                  string
+	topLeft, topMiddle, topRight          string
+	middleLeft, middle, middleRight       string
+	bottomLeft, bottomMiddle, bottomRight string
+}
+
+var mapTableBorderByStyle = map[string]tableBorder{
+	tableBox: {
+		horizontal: "─", vertical: "│",
+		topLeft: "┌", topMiddle: "┬", topRight: "┐",
+		middleLeft: "├", middle: "┼", middleRight: "┤",
+		bottomLeft: "└", bottomMiddle: "┴", bottomRight: "┘",
+	},
+	// for the terminals that can't render the box-drawing characters
+	tableASCII: {
+		horizontal: "-", vertical: "|",
+		topLeft: "+", topMiddle: "+", topRight: "+",
+		middleLeft: "+", middle: "+", middleRight: "+",
+		bottomLeft: "+", bottomMiddle: "+", bottomRight: "+",
+	},
+}
+
+// printTable prints the columns of the long format of the files inside a table
+// with borders and a header row of the column names.
+func printTable(fs []file, columns []string, opts options) {
+	border := mapTableBorderByStyle[opts.tableBorder]
+	cells, cellWidths, widths := columnCells(fs, columns, opts)
+	for j, name := range columns {
+		widths[j] = max(widths[j], runewidth.StringWidth(name))
+	}
+
+	rule := func(left, middle, right string) {
+		parts := make([]string, len(widths))
+		for j, width := range widths {
+			parts[j] = strings.Repeat(border.horizontal, width+2)
+		}
+		fmt.Println(left + strings.Join(parts, middle) + right)
+	}
+	row := func(values []string, valueWidths []int, headers bool) {
+		parts := make([]string, len(values))
+		for j, value := range values {
+			right := mapLongColumnByName[columns[j]].right && !headers
+			parts[j] = " " + padCell(value, valueWidths[j], widths[j], right) + " "
+		}
+		fmt.Println(border.vertical + strings.Join(parts, border.vertical) + border.vertical)
+	}
+
+	headerWidths := make([]int, len(columns))
+	for j, name := range columns {
+		headerWidths[j] = runewidth.StringWidth(name)
+	}
+
+	rule(border.topLeft, border.topMiddle, border.topRight)
+	row(columns, headerWidths, true)
+	rule(border.middleLeft, border.middle, border.middleRight)
+	for i := range fs {
+		row(cells[i], cellWidths[i], false)
+	}
+	rule(border.bottomLeft, border.bottomMiddle, border.bottomRight)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+)
+
+// fileTemplate is the view of a file given to the --format template
+type fileTemplate struct {
+	Name       string
+	Path       string
+	Size       int64
+	Mode       string
+	ModTime    time.Time
+	AccessTime time.Time
+	ChangeTime time.Time
+	Type       string
+	Owner      string
+	Group      string
+	Inode      uint64
+	Links      uint64
+	LinkTarget string
+	GitStatus  string
+	IsDir      bool
+	IsHidden   bool
+}
+
+// newFileTemplate returns the template view of the given file.
+func newFileTemplate(f file) fileTemplate {
+	return fileTemplate{
+		Name:       f.name,
+		Path:       f.path,
+		Size:       f.size,
+		Mode:       f.mode,
+		ModTime:    f.modificationTime,
+		AccessTime: f.accessTime,
+		ChangeTime: f.changeTime,
+		Type:       mapNameByFileType[f.fileType],
+		Owner:      f.userName,
+		Group:      f.groupName,
+		Inode:      f.inode,
+		Links:      f.nlinks,
+		LinkTarget: f.linkTarget,
+		GitStatus:  f.gitStatus,
+		IsDir:      f.isDir,
+		IsHidden:   f.isHidden,
+	}
+}
+
+// templateFuncs are the helper functions of the --format template
+var templateFuncs = template.FuncMap{
+	"human": func(size int64) string {
+		return humanizeSize(size, binaryBase)
+	},
+	"si": func(size int64) string {
+		return humanizeSize(size, siBase)
+	},
+	"relative": humanizeTime,
+	"date": func(layout string, t time.Time) string {
+		return t.Format(timeLayout(layout))
+	},
+}
+
+// compileFormat compiles the --format template, like "{{.Name}} {{human .Size}}".
+func compileFormat(format string) (*template.Template, error) {
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// printTemplate prints each file with the --format template, one per line.
+func printTemplate(fs []file, tmpl *template.Template) error {
+	for _, f := range fs {
+		if err := tmpl.Execute(os.Stdout, newFileTemplate(f)); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	return nil
+}
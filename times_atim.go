@@ -0,0 +1,20 @@
+//go:build linux || openbsd || dragonfly || solaris
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// getFileTimes returns the access and status change times of the file,
+// or its modification time when the stat information is not available.
+func getFileTimes(info os.FileInfo) (accessTime, changeTime time.Time) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.ModTime(), info.ModTime()
+	}
+
+	return time.Unix(stat.Atim.Unix()), time.Unix(stat.Ctim.Unix())
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !openbsd && !dragonfly && !solaris && !darwin && !freebsd && !netbsd && !windows
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// getFileTimes returns the modification time as the access and status change
+// times because this system has no known stat information.
+func getFileTimes(info os.FileInfo) (accessTime, changeTime time.Time) {
+	return info.ModTime(), info.ModTime()
+}
@@ -0,0 +1,30 @@
+//go:build darwin || freebsd || netbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// getFileTimes returns the access and status change times of the file,
+// or its modification time when the stat information is not available.
+func getFileTimes(info os.FileInfo) (accessTime, changeTime time.Time) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.ModTime(), info.ModTime()
+	}
+
+	return time.Unix(stat.Atimespec.Unix()), time.Unix(stat.Ctimespec.Unix())
+}
+
+// getBirthTime returns the creation time of the file from its stat information.
+func getBirthTime(name string, info os.FileInfo) (time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(stat.Birthtimespec.Unix()), true
+}
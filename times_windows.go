@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// getFileTimes returns the access time of the file, windows has no status
+// change time so the modification time is returned instead.
+func getFileTimes(info os.FileInfo) (accessTime, changeTime time.Time) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return info.ModTime(), info.ModTime()
+	}
+
+	return time.Unix(0, data.LastAccessTime.Nanoseconds()), info.ModTime()
+}
+
+// getBirthTime returns the creation time of the file from its attributes.
+func getBirthTime(name string, info os.FileInfo) (time.Time, bool) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, data.CreationTime.Nanoseconds()), true
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/fatih/color"
+)
+
+// tree connectors
+const (
+	treeBranch     = "├── "
+	treeLastBranch = "└── "
+	treeIndent     = "│   "
+	treeLastIndent = "    "
+)
+
+// printTree prints the given directory and its subdirectories
+// as an indented tree like the tree command.
+func printTree(path string, opts options) error {
+	// ancestors holds the directories of the current branch to avoid symlink cycles with -L
+	ancestors := map[fileKey]bool{}
+	if _, err := enterDir(path, ancestors); err != nil {
+		return err
+	}
+
+	// the tree draws the parents itself, so it has no . and .. entries
+	opts.almostAll = opts.all
+
+	fmt.Println(setColor(path, color.FgBlue))
+	printTreeLevel(path, "", 1, opts, ancestors)
+	return nil
+}
+
+// printTreeLevel prints the files of the given directory depth-first,
+// prefix holds the connectors drawn by the parent levels.
+// Errors reading a subdirectory are reported without stopping the walk,
+// like the links back to a parent directory which are not walked again.
+func printTreeLevel(path, prefix string, level int, opts options, ancestors map[fileKey]bool) {
+	fs, err := readFiles(path, opts)
+	if err != nil {
+		reportError(err)
+		return
+	}
+
+	for i, f := range fs {
+		connector, indent := treeBranch, treeIndent
+		if i == len(fs)-1 {
+			connector, indent = treeLastBranch, treeLastIndent
+		}
+
+		fmt.Printf("%s%s%s\n", prefix, connector, formatName(f, opts))
+
+		// a level of 0 means no depth limit
+		if f.isDir && (opts.treeLevel == 0 || level < opts.treeLevel) {
+			subPath := filepath.Join(path, f.name)
+			key, err := enterDir(subPath, ancestors)
+			if err != nil {
+				reportError(err)
+				continue
+			}
+			printTreeLevel(subPath, prefix+indent, level+1, opts, ancestors)
+			delete(ancestors, key)
+		}
+	}
+}
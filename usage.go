@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// version is the build version of edls, injected at build time with
+// go build -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// usageExamples are printed at the end of the --help output
+const usageExamples = `
+Examples:
+  edls -la                      long listing including the hidden files
+  edls --all --long             the same with the long flags
+  edls -lsr -n 10 ~/Downloads   the ten biggest files of the downloads
+  edls -1p '\.go$'              the go files, one per line
+  edls --tree --level 2         the tree of the current directory, two levels deep
+  edls -j | jq '.[].name'       the file names through jq
+`
+
+// usage prints the description of edls, its flags and some examples.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "edls %s lists the files of the directories, by default the current one.\n\n", version)
+	fmt.Fprintf(out, "Usage:\n  edls [flags] [path ...]\n\nFlags:\n")
+	flag.PrintDefaults()
+	fmt.Fprint(out, usageExamples)
+}
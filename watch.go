@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultPollInterval is how often --watch checks the paths without --interval
+const defaultPollInterval = 2 * time.Second
+
+// watchDebounce groups the bursts of changes, like a download or a build, in one refresh
+const watchDebounce = 100 * time.Millisecond
+
+// watchPaths lists the paths and lists them again whenever they change until
+// it's interrupted with Ctrl-C. Without change notifications from the system
+// it checks the paths every --interval.
+func watchPaths(paths []string, opts options) {
+	interrupt := notifyInterrupt()
+	defer signal.Stop(interrupt)
+
+	render(paths, opts)
+
+	watcher, err := newWatcher(paths)
+	if err != nil {
+		if opts.interval == 0 {
+			opts.interval = defaultPollInterval
+		}
+		reportError(fmt.Errorf("cannot watch the changes, checking every %s: %v", opts.interval, err))
+		pollPaths(paths, opts, interrupt)
+		return
+	}
+	defer watcher.Close()
+
+	var refresh <-chan time.Time
+	for {
+		select {
+		case <-watcher.Events:
+			if refresh == nil {
+				refresh = time.After(watchDebounce)
+			}
+		case <-refresh:
+			refresh = nil
+			render(paths, opts)
+		case err := <-watcher.Errors:
+			reportError(err)
+		case <-interrupt:
+			return
+		}
+	}
+}
+
+// refreshPaths lists the paths every --interval like watch edls, until it's
+// interrupted with Ctrl-C. The listings are sorted again each time, so the
+// order only changes when the files do.
+func refreshPaths(paths []string, opts options) {
+	interrupt := notifyInterrupt()
+	defer signal.Stop(interrupt)
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	render(paths, opts)
+	for {
+		select {
+		case <-ticker.C:
+			render(paths, opts)
+		case <-interrupt:
+			return
+		}
+	}
+}
+
+// notifyInterrupt returns the channel of the Ctrl-C and termination signals,
+// which stop the refreshes cleanly.
+func notifyInterrupt() chan os.Signal {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	return interrupt
+}
+
+// newWatcher returns a watcher of the changes of the paths.
+func newWatcher(paths []string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		if err := watcher.Add(path); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+	return watcher, nil
+}
+
+// pollPaths lists the paths again when their modification times change,
+// checking them every --interval until it's interrupted.
+func pollPaths(paths []string, opts options, interrupt <-chan os.Signal) {
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	last := pathsState(paths)
+	for {
+		select {
+		case <-ticker.C:
+			if state := pathsState(paths); state != last {
+				last = state
+				render(paths, opts)
+			}
+		case <-interrupt:
+			return
+		}
+	}
+}
+
+// pathsState returns the modification times and sizes of the paths, which
+// change when a file of a directory is added, removed or renamed.
+func pathsState(paths []string) string {
+	var state string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			state += fmt.Sprintf("%s %d %d\n", path, info.ModTime().UnixNano(), info.Size())
+		}
+	}
+	return state
+}
+
+// render clears the terminal and lists the paths, the screen is only
+// cleared on a terminal so the output of a pipe keeps every listing.
+func render(paths []string, opts options) {
+	if isTerminal() {
+		fmt.Print("\x1b[H\x1b[2J")
+	}
+	listPaths(paths, opts)
+}